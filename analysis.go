@@ -0,0 +1,676 @@
+// MIT License
+//
+// Copyright (c) 2022 geozelot (André Siefken)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package boxtree
+
+import (
+	"math"
+	gosort "sort"
+)
+
+// CoverageProfile is the coverage histogram function;
+// divides the tree's range along axis ax into buckets bins of equal width and counts the boxes overlapping each bin.
+//
+// Bins are half-open [lo, hi), except the last one, which includes the upper end of the range;
+// a box ending exactly on a bin edge counts towards both adjacent bins.
+// If all boxes share a single coordinate on ax, all of them are counted in the first bin.
+func (boT *BOXTree) CoverageProfile(ax int, buckets int) []int {
+
+	if buckets < 1 {
+		return []int{}
+	}
+
+	res := make([]int, buckets+1)
+
+	if len(boT.idxs) == 0 {
+		return res[:buckets]
+	}
+
+	min, max := math.Inf(1), math.Inf(-1)
+
+	for i := range boT.idxs {
+
+		if boT.lmts[2*i][ax] < min {
+			min = boT.lmts[2*i][ax]
+		}
+
+		if boT.lmts[2*i+1][ax] > max {
+			max = boT.lmts[2*i+1][ax]
+		}
+
+	}
+
+	wd := (max - min) / float64(buckets)
+
+	for i := range boT.idxs {
+
+		lb, rb := 0, 0
+
+		if wd > 0 {
+			lb = bucket(boT.lmts[2*i][ax], min, wd, buckets)
+			rb = bucket(boT.lmts[2*i+1][ax], min, wd, buckets)
+		}
+
+		res[lb]++
+		res[rb+1]--
+
+	}
+
+	for i := 1; i < buckets; i++ {
+		res[i] += res[i-1]
+	}
+
+	return res[:buckets]
+
+}
+
+// bucket is an internal utility function, mapping a value to its bin index clamped to [0, buckets-1].
+func bucket(val, min, wd float64, buckets int) int {
+
+	b := int(math.Floor((val - min) / wd))
+
+	if b < 0 {
+		return 0
+	}
+
+	if b >= buckets {
+		return buckets - 1
+	}
+
+	return b
+
+}
+
+// IsFullyCovered is the coverage gap test;
+// checks whether the box given by its limits is entirely covered by the union of all stored boxes (inclusive).
+func (boT *BOXTree) IsFullyCovered(lower, upper []float64) bool {
+
+	cns, xs := boT.slabs(lower, upper)
+
+	if len(cns) == 0 {
+		return false
+	}
+
+	ivs := make([][2]float64, 0, len(cns))
+
+	for i := 0; i < len(xs)-1; i++ {
+
+		ivs = boT.spans(cns, (xs[i]+xs[i+1])/2.0, ivs[:0])
+
+		if !covers(ivs, lower[1], upper[1]) {
+			return false
+		}
+
+	}
+
+	return true
+
+}
+
+// UncoveredRegions is the coverage gap extraction function;
+// returns boxes as {lower, upper} tiling the parts of the box given by its limits not covered by any stored box.
+//
+// The window is cut into slabs at the x edges of the intersecting boxes, and gaps spanning consecutive slabs identically are merged.
+// Uncovered parts of zero area (e.g. seams between adjacent boxes) are not reported.
+func (boT *BOXTree) UncoveredRegions(lower, upper []float64) [][2][]float64 {
+
+	res := [][2][]float64{}
+	cns, xs := boT.slabs(lower, upper)
+
+	ivs := make([][2]float64, 0, len(cns))
+	prv := [][2]float64{}
+	off := 0
+
+	for i := 0; i < len(xs)-1; i++ {
+
+		ivs = boT.spans(cns, (xs[i]+xs[i+1])/2.0, ivs[:0])
+		gps := gaps(ivs, lower[1], upper[1])
+
+		if len(gps) == len(prv) && i > 0 {
+
+			eq := true
+
+			for j := range gps {
+				eq = eq && gps[j] == prv[j]
+			}
+
+			if eq {
+
+				for j := range gps {
+					res[off+j][1][0] = xs[i+1]
+				}
+
+				continue
+
+			}
+
+		}
+
+		off = len(res)
+
+		for _, gp := range gps {
+			res = append(res, [2][]float64{{xs[i], gp[0]}, {xs[i+1], gp[1]}})
+		}
+
+		prv = gps
+
+	}
+
+	return res
+
+}
+
+// ScanlineCoverage is the scanline rasterization function;
+// returns the merged x intervals within xRange covered by at least one stored box at the given y value (inclusive), sorted ascending.
+//
+// Intervals of touching or overlapping boxes are merged into one; covered parts of zero length (e.g. boxes touching the range ends) are not reported.
+func (boT *BOXTree) ScanlineCoverage(y float64, xRange [2]float64) [][2]float64 {
+
+	res := [][2]float64{}
+	ivs := [][2]float64{}
+
+	boT.traverseBox([]float64{xRange[0], y}, []float64{xRange[1], y}, func(cn int) bool {
+
+		ivs = append(ivs, [2]float64{math.Max(boT.lmts[2*cn][0], xRange[0]), math.Min(boT.lmts[2*cn+1][0], xRange[1])})
+		return true
+
+	})
+
+	gosort.Slice(ivs, func(i, j int) bool {
+		return ivs[i][0] < ivs[j][0]
+	})
+
+	for _, iv := range ivs {
+
+		if n := len(res); n > 0 && iv[0] <= res[n-1][1] {
+
+			res[n-1][1] = math.Max(res[n-1][1], iv[1])
+			continue
+
+		}
+
+		res = append(res, iv)
+
+	}
+
+	cov := res[:0]
+
+	for _, iv := range res {
+
+		if iv[0] < iv[1] {
+			cov = append(cov, iv)
+		}
+
+	}
+
+	return cov
+
+}
+
+// slabs is an internal utility function for coverage computations;
+// collects the node positions of boxes intersecting the given limits and the sorted distinct x edges cutting them into slabs.
+func (boT *BOXTree) slabs(lower, upper []float64) (cns []int, xs []float64) {
+
+	boT.traverseBox(lower, upper, func(cn int) bool {
+
+		cns = append(cns, cn)
+		return true
+
+	})
+
+	xs = []float64{lower[0], upper[0]}
+
+	for _, cn := range cns {
+
+		for _, x := range []float64{boT.lmts[2*cn][0], boT.lmts[2*cn+1][0]} {
+
+			if lower[0] < x && x < upper[0] {
+				xs = append(xs, x)
+			}
+
+		}
+
+	}
+
+	xs = unique(xs)
+
+	if len(xs) == 1 {
+		xs = append(xs, xs[0])
+	}
+
+	return cns, xs
+
+}
+
+// spans is an internal utility function, appending the y intervals of the given nodes covering the x value md to ivs.
+func (boT *BOXTree) spans(cns []int, md float64, ivs [][2]float64) [][2]float64 {
+
+	for _, cn := range cns {
+
+		l, u := boT.lmts[2*cn], boT.lmts[2*cn+1]
+
+		if l[0] <= md && md <= u[0] {
+			ivs = append(ivs, [2]float64{l[1], u[1]})
+		}
+
+	}
+
+	return ivs
+
+}
+
+// gaps is an internal utility function, finding the parts of [lo, hi] of positive length not covered by the given closed intervals.
+func gaps(ivs [][2]float64, lo, hi float64) [][2]float64 {
+
+	gosort.Slice(ivs, func(i, j int) bool {
+		return ivs[i][0] < ivs[j][0]
+	})
+
+	res := [][2]float64{}
+
+	for _, iv := range ivs {
+
+		if iv[0] > lo {
+			res = append(res, [2]float64{lo, math.Min(iv[0], hi)})
+		}
+
+		if iv[1] > lo {
+			lo = iv[1]
+		}
+
+		if lo >= hi {
+			return res
+		}
+
+	}
+
+	return append(res, [2]float64{lo, hi})
+
+}
+
+// covers is an internal utility function, checking whether the union of the given closed intervals covers [lo, hi].
+func covers(ivs [][2]float64, lo, hi float64) bool {
+
+	gosort.Slice(ivs, func(i, j int) bool {
+		return ivs[i][0] < ivs[j][0]
+	})
+
+	for _, iv := range ivs {
+
+		if iv[0] > lo {
+			return false
+		}
+
+		if iv[1] > lo {
+			lo = iv[1]
+		}
+
+		if lo >= hi {
+			return true
+		}
+
+	}
+
+	return false
+
+}
+
+// unique is an internal utility function, sorting the given values and removing exact duplicates in place.
+func unique(vals []float64) []float64 {
+
+	gosort.Float64s(vals)
+
+	n := 0
+
+	for i, v := range vals {
+
+		if i == 0 || v != vals[n-1] {
+			vals[n] = v
+			n++
+		}
+
+	}
+
+	return vals[:n]
+
+}
+
+// Breakpoints is the edge extraction function for conforming grids;
+// returns the sorted distinct lower and upper limits on axis ax of all boxes intersecting the box given by its limits, clipped to it.
+//
+// Edges are only merged if exactly equal (==); near-coincident edges differing by rounding errors are all returned.
+func (boT *BOXTree) Breakpoints(lower, upper []float64, ax int) []float64 {
+
+	res := []float64{}
+
+	boT.traverseBox(lower, upper, func(cn int) bool {
+
+		res = append(res, math.Max(boT.lmts[2*cn][ax], lower[ax]), math.Min(boT.lmts[2*cn+1][ax], upper[ax]))
+		return true
+
+	})
+
+	return unique(res)
+
+}
+
+// HitCounts is the inverse batch query;
+// returns, per original box index, the number of the given points overlapping that box.
+//
+// Boxes collapsed by WithDedup receive the count of their group.
+func (boT *BOXTree) HitCounts(points [][]float64) []int {
+
+	res := make([]int, boT.size)
+
+	for _, vals := range points {
+
+		if boT.scans() {
+
+			boT.scanBox(vals, vals, func(cn int) {
+				res[boT.idxs[cn]]++
+			})
+
+			continue
+
+		}
+
+		boT.traverse(vals, func(cn int) bool {
+
+			res[boT.idxs[cn]]++
+			return true
+
+		})
+
+	}
+
+	for _, grp := range boT.dups {
+
+		for _, idx := range grp[1:] {
+			res[idx] = res[grp[0]]
+		}
+
+	}
+
+	return res
+
+}
+
+// FirstHit is the labeling batch query;
+// returns, per given point, the index of one box overlapping it, or -1 if there is none.
+//
+// The box returned is the first one found in traversal order, where the traversal of that point stops; which box that is,
+// among several overlapping ones, is unspecified and may vary between builds.
+func (boT *BOXTree) FirstHit(points [][]float64) []int {
+
+	res := make([]int, len(points))
+
+	for i, vals := range points {
+
+		res[i] = -1
+
+		boT.traverse(vals, func(cn int) bool {
+
+			res[i] = boT.idxs[cn]
+			return false
+
+		})
+
+	}
+
+	return res
+
+}
+
+// DistinctHits is the union batch query;
+// returns the number of distinct boxes overlapping at least one of the given points.
+//
+// Boxes collapsed by WithDedup count with their whole group.
+func (boT *BOXTree) DistinctHits(points [][]float64) int {
+
+	seen := make([]bool, len(boT.idxs))
+	cnt := 0
+
+	for _, vals := range points {
+
+		boT.traverse(vals, func(cn int) bool {
+
+			if !seen[cn] {
+				seen[cn], cnt = true, cnt+1
+			}
+
+			return true
+
+		})
+
+	}
+
+	if boT.dups != nil {
+
+		pos := boT.Positions()
+
+		for _, grp := range boT.dups {
+
+			if seen[pos[grp[0]]] {
+				cnt += len(grp) - 1
+			}
+
+		}
+
+	}
+
+	return cnt
+
+}
+
+// OverlapDegrees is the self-join clutter function;
+// returns, per original box index, the number of other stored boxes intersecting that box, shared edges included.
+//
+// Runs one box query per stored box, in O(n log n + k) for k intersecting pairs rather than O(n²). Boxes collapsed by WithDedup
+// count as the separate boxes they are, each intersecting all its copies.
+func (boT *BOXTree) OverlapDegrees() []int {
+
+	res := make([]int, boT.size)
+	wts := make([]int, len(boT.idxs))
+
+	for i := range wts {
+		wts[i] = 1
+	}
+
+	pos := boT.Positions()
+
+	for _, grp := range boT.dups {
+		wts[pos[grp[0]]] = len(grp)
+	}
+
+	for p, idx := range boT.idxs {
+
+		n := -1
+
+		boT.traverseBox(boT.lmts[2*p], boT.lmts[2*p+1], func(cn int) bool {
+
+			n += wts[cn]
+			return true
+
+		})
+
+		res[idx] = n
+
+	}
+
+	for _, grp := range boT.dups {
+
+		for _, idx := range grp[1:] {
+			res[idx] = res[grp[0]]
+		}
+
+	}
+
+	return res
+
+}
+
+// UnionArea is the total coverage function;
+// calculates the area of the union of all stored boxes, counting overlapping parts once, via a sweep line over their x edges.
+func (boT *BOXTree) UnionArea() float64 {
+
+	evs := make([][4]float64, 0, 2*len(boT.idxs))
+	ys := make([]float64, 0, 2*len(boT.idxs))
+
+	for i := range boT.idxs {
+
+		l, u := boT.lmts[2*i], boT.lmts[2*i+1]
+
+		if l[0] < u[0] && l[1] < u[1] {
+
+			evs = append(evs, [4]float64{l[0], 1, l[1], u[1]}, [4]float64{u[0], -1, l[1], u[1]})
+			ys = append(ys, l[1], u[1])
+
+		}
+
+	}
+
+	if len(evs) == 0 {
+		return 0
+	}
+
+	gosort.Slice(evs, func(i, j int) bool {
+		return evs[i][0] < evs[j][0]
+	})
+
+	sw := sweep{ys: unique(ys)}
+	sw.cnt = make([]int, 4*len(sw.ys))
+	sw.len = make([]float64, 4*len(sw.ys))
+
+	area, px := 0.0, evs[0][0]
+
+	for _, ev := range evs {
+
+		area += sw.len[1] * (ev[0] - px)
+		px = ev[0]
+
+		lb, rb := gosort.SearchFloat64s(sw.ys, ev[2]), gosort.SearchFloat64s(sw.ys, ev[3])
+		sw.update(1, 0, len(sw.ys)-1, lb, rb, int(ev[1]))
+
+	}
+
+	return area
+
+}
+
+// sweep is the internal segment tree for sweep line coverage;
+// holds the distinct edge coordinates and, per tree node, the number of covering intervals and the covered length.
+type sweep struct {
+	ys  []float64
+	cnt []int
+	len []float64
+}
+
+// update is the internal segment tree modification function;
+// adds v to the coverage count of the edge range [lb, rb) below node nd spanning [lo, hi) and refreshes the covered lengths.
+func (sw *sweep) update(nd, lo, hi, lb, rb, v int) {
+
+	if rb <= lo || hi <= lb {
+		return
+	}
+
+	if lb <= lo && hi <= rb {
+
+		sw.cnt[nd] += v
+
+	} else {
+
+		md := (lo + hi) >> 1
+
+		sw.update(2*nd, lo, md, lb, rb, v)
+		sw.update(2*nd+1, md, hi, lb, rb, v)
+
+	}
+
+	if sw.cnt[nd] > 0 {
+		sw.len[nd] = sw.ys[hi] - sw.ys[lo]
+	} else if hi-lo > 1 {
+		sw.len[nd] = sw.len[2*nd] + sw.len[2*nd+1]
+	} else {
+		sw.len[nd] = 0
+	}
+
+}
+
+// RankByAxis is the order statistic query;
+// counts the boxes lying entirely below the given values on each axis, i.e. with an upper limit less than the value.
+//
+// Subtrees split on the counted axis are taken or skipped as a whole by their augmented limits; boxes collapsed by WithDedup are counted once.
+func (boT *BOXTree) RankByAxis(vals []float64) (belowX, belowY int) {
+
+	return boT.rank(vals, 0), boT.rank(vals, 1)
+
+}
+
+// rank is the internal single axis counting function of RankByAxis.
+func (boT *BOXTree) rank(vals []float64, a int) int {
+
+	cnt := 0
+
+	descend(nil, len(boT.idxs), boT.ax0, boT.step(), 2, func(lb, rb, cn, ax int) (left, right, ok bool) {
+
+		ag := boT.ags[2*cn : 2*cn+2]
+
+		if ax == a {
+
+			if ag[0] < vals[a] {
+
+				cnt += rb - lb + 1
+				return false, false, true
+
+			}
+
+			if ag[1] >= vals[a] {
+				return false, false, true
+			}
+
+		}
+
+		if rb-lb < boT.leaf {
+
+			for i := lb; i <= rb; i++ {
+
+				if boT.lmts[2*i+1][a] < vals[a] {
+					cnt++
+				}
+
+			}
+
+			return false, false, true
+
+		}
+
+		if boT.lmts[2*cn+1][a] < vals[a] {
+			cnt++
+		}
+
+		return true, ax != a || boT.lmts[2*cn][a] < vals[a], true
+
+	})
+
+	return cnt
+
+}
@@ -0,0 +1,503 @@
+package boxtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRankByAxisMatchesBruteForce(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(9))
+
+	for _, lf := range []int{0, 8} {
+
+		bxs := randomBoxes(rng, 2000, 100, 10)
+		tree := NewBOXTree(bxs, WithLeafSize(lf))
+
+		for q := 0; q < 300; q++ {
+
+			vals := []float64{rng.Float64() * 120, rng.Float64() * 120}
+			want := [2]int{}
+
+			for _, bx := range bxs {
+
+				_, u := bx.Limits()
+
+				for a := 0; a < 2; a++ {
+
+					if u[a] < vals[a] {
+						want[a]++
+					}
+
+				}
+
+			}
+
+			if x, y := tree.RankByAxis(vals); x != want[0] || y != want[1] {
+				t.Fatalf("leaf %d, RankByAxis(%v) = %d, %d, want %v", lf, vals, x, y, want)
+			}
+
+		}
+
+	}
+
+}
+
+func TestCoverageProfileKnownHistogram(t *testing.T) {
+
+	spans := [][2]float64{{0, 10}, {0, 5}, {5, 10}, {2, 3}, {0, 4}}
+	bxs := make([]Box, len(spans))
+
+	for i, sp := range spans {
+		bxs[i] = &testBox{[]float64{sp[0], 100}, []float64{sp[1], 200}}
+	}
+
+	tree := NewBOXTree(bxs)
+
+	// bins of width 2 over [0, 10]; {0, 4} ends on the edge of bins 1 and 2 and counts towards both,
+	// {0, 5} and {5, 10} meet inside bin 2
+	if got, want := tree.CoverageProfile(0, 5), []int{3, 4, 4, 2, 2}; !equalInts(got, want) {
+		t.Fatalf("CoverageProfile(0, 5) = %v, want %v", got, want)
+	}
+
+	if got, want := tree.CoverageProfile(0, 1), []int{5}; !equalInts(got, want) {
+		t.Fatalf("CoverageProfile(0, 1) = %v, want %v", got, want)
+	}
+
+	// all boxes span the whole y range [100, 200]
+	if got, want := tree.CoverageProfile(1, 4), []int{5, 5, 5, 5}; !equalInts(got, want) {
+		t.Fatalf("CoverageProfile(1, 4) = %v, want %v", got, want)
+	}
+
+	if got := tree.CoverageProfile(0, 0); len(got) != 0 {
+		t.Fatalf("CoverageProfile(0, 0) = %v, want empty", got)
+	}
+
+	flat := NewBOXTree([]Box{&testBox{[]float64{1, 0}, []float64{1, 1}}, &testBox{[]float64{1, 2}, []float64{1, 3}}})
+
+	if got, want := flat.CoverageProfile(0, 3), []int{2, 0, 0}; !equalInts(got, want) {
+		t.Fatalf("single coordinate CoverageProfile(0, 3) = %v, want %v", got, want)
+	}
+
+}
+
+func TestIsFullyCoveredGap(t *testing.T) {
+
+	bxs := []Box{}
+
+	for x := 0; x < 10; x++ {
+
+		for y := 0; y < 10; y++ {
+
+			if x == 4 && y == 6 {
+				continue
+			}
+
+			bxs = append(bxs, &testBox{[]float64{float64(x), float64(y)}, []float64{float64(x + 1), float64(y + 1)}})
+
+		}
+
+	}
+
+	tree := NewBOXTree(bxs, WithLeafSize(4))
+
+	for _, tc := range []struct {
+		lower, upper []float64
+		want         bool
+	}{
+		{[]float64{0, 0}, []float64{10, 10}, false},
+		{[]float64{4.2, 6.2}, []float64{4.8, 6.8}, false},
+		{[]float64{3.5, 5.5}, []float64{4.1, 6.1}, false},
+		{[]float64{0, 0}, []float64{10, 6}, true},
+		{[]float64{0, 0}, []float64{4, 10}, true},
+		{[]float64{5, 0}, []float64{10, 10}, true},
+		{[]float64{0.5, 0.5}, []float64{9.5, 5.5}, true},
+		{[]float64{4.5, 6}, []float64{4.5, 6}, true},
+		{[]float64{4.5, 6.5}, []float64{4.5, 6.5}, false},
+		{[]float64{9, 9}, []float64{10.5, 10}, false},
+	} {
+
+		if got := tree.IsFullyCovered(tc.lower, tc.upper); got != tc.want {
+			t.Fatalf("IsFullyCovered(%v, %v) = %v, want %v", tc.lower, tc.upper, got, tc.want)
+		}
+
+	}
+
+}
+
+func TestUncoveredRegionsLShape(t *testing.T) {
+
+	tree := NewBOXTree([]Box{
+		&testBox{[]float64{0, 0}, []float64{6, 4}},
+		&testBox{[]float64{0, 4}, []float64{3, 8}},
+		&testBox{[]float64{20, 20}, []float64{30, 30}},
+	})
+
+	got := tree.UncoveredRegions([]float64{0, 0}, []float64{10, 10})
+	want := [][2][]float64{
+		{{0, 8}, {3, 10}},
+		{{3, 4}, {6, 10}},
+		{{6, 0}, {10, 10}},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("UncoveredRegions = %v, want %v", got, want)
+	}
+
+	area := 0.0
+
+	for i := range want {
+
+		for j := 0; j < 2; j++ {
+
+			if got[i][0][j] != want[i][0][j] || got[i][1][j] != want[i][1][j] {
+				t.Fatalf("UncoveredRegions = %v, want %v", got, want)
+			}
+
+		}
+
+		area += (got[i][1][0] - got[i][0][0]) * (got[i][1][1] - got[i][0][1])
+
+	}
+
+	// window minus the 6x4 and 3x4 boxes
+	if area != 100-24-12 {
+		t.Fatalf("uncovered area = %v, want %v", area, 100-24-12)
+	}
+
+	if got := tree.UncoveredRegions([]float64{1, 1}, []float64{5, 3}); len(got) != 0 {
+		t.Fatalf("UncoveredRegions of a covered window = %v, want none", got)
+	}
+
+	if got := tree.UncoveredRegions([]float64{11, 11}, []float64{12, 12}); len(got) != 1 || got[0][0][0] != 11 || got[0][1][1] != 12 {
+		t.Fatalf("UncoveredRegions of an empty window = %v, want the window", got)
+	}
+
+}
+
+func TestUnionAreaHandComputed(t *testing.T) {
+
+	tree := NewBOXTree([]Box{
+		&testBox{[]float64{0, 0}, []float64{4, 4}},
+		&testBox{[]float64{2, 2}, []float64{6, 6}},
+		&testBox{[]float64{1, 1}, []float64{3, 3}},
+		&testBox{[]float64{10, 10}, []float64{11, 12}},
+		&testBox{[]float64{0, 0}, []float64{0, 5}},
+	})
+
+	// 16 + 16 - 4 overlap, the contained box adds nothing, 1x2, the zero area box adds nothing
+	if got := tree.UnionArea(); got != 30 {
+		t.Fatalf("UnionArea = %v, want 30", got)
+	}
+
+	if got := NewBOXTree([]Box{}).UnionArea(); got != 0 {
+		t.Fatalf("empty UnionArea = %v, want 0", got)
+	}
+
+}
+
+func TestUnionAreaMatchesBruteForce(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(41))
+
+	for r := 0; r < 20; r++ {
+
+		bxs := make([]Box, 50)
+		cov := [40][40]bool{}
+
+		for i := range bxs {
+
+			x, y := rng.Intn(30), rng.Intn(30)
+			w, h := rng.Intn(10), rng.Intn(10)
+			bxs[i] = &testBox{[]float64{float64(x), float64(y)}, []float64{float64(x + w), float64(y + h)}}
+
+			for cx := x; cx < x+w; cx++ {
+
+				for cy := y; cy < y+h; cy++ {
+					cov[cx][cy] = true
+				}
+
+			}
+
+		}
+
+		want := 0.0
+
+		for cx := range cov {
+
+			for cy := range cov[cx] {
+
+				if cov[cx][cy] {
+					want++
+				}
+
+			}
+
+		}
+
+		if got := NewBOXTree(bxs).UnionArea(); got != want {
+			t.Fatalf("round %d: UnionArea = %v, want %v cells", r, got, want)
+		}
+
+	}
+
+}
+
+func TestHitCountsMatchesBruteForce(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(58))
+	bxs := randomBoxes(rng, 1000, 100, 10)
+
+	// exact copies, which WithDedup collapses
+	for i := 0; i < 50; i++ {
+		bxs = append(bxs, bxs[rng.Intn(len(bxs))])
+	}
+
+	points := make([][]float64, 2000)
+
+	for i := range points {
+		points[i] = []float64{rng.Float64() * 110, rng.Float64() * 110}
+	}
+
+	want := make([]int, len(bxs))
+
+	for _, vals := range points {
+
+		for _, idx := range bruteOverlaps(bxs, vals) {
+			want[idx]++
+		}
+
+	}
+
+	for _, opts := range [][]Option{{}, {WithLeafSize(8)}, {WithDedup()}, {WithColumnar()}} {
+
+		if got := NewBOXTree(bxs, opts...).HitCounts(points); !equalInts(got, want) {
+			t.Fatalf("HitCounts = %v, want %v", got, want)
+		}
+
+	}
+
+	if got := NewBOXTree(bxs).HitCounts(nil); !equalInts(got, make([]int, len(bxs))) {
+		t.Fatalf("HitCounts without points = %v, want all 0", got)
+	}
+
+}
+
+func TestDistinctHitsMatchesBruteForce(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(67))
+	bxs := randomBoxes(rng, 1000, 100, 10)
+
+	for i := 0; i < 50; i++ {
+		bxs = append(bxs, bxs[rng.Intn(len(bxs))])
+	}
+
+	for _, n := range []int{0, 1, 10, 500} {
+
+		points := make([][]float64, n)
+		set := map[int]bool{}
+
+		for i := range points {
+
+			points[i] = []float64{rng.Float64() * 110, rng.Float64() * 110}
+
+			for _, idx := range bruteOverlaps(bxs, points[i]) {
+				set[idx] = true
+			}
+
+		}
+
+		// repeated points must not count twice
+		points = append(points, points...)
+
+		for _, opts := range [][]Option{{}, {WithLeafSize(8)}, {WithDedup()}} {
+
+			if got := NewBOXTree(bxs, opts...).DistinctHits(points); got != len(set) {
+				t.Fatalf("%d points: DistinctHits = %d, want %d", n, got, len(set))
+			}
+
+		}
+
+	}
+
+}
+
+// bruteDegrees counts, per box, the other boxes intersecting it by checking all pairs.
+func bruteDegrees(bxs []Box) []int {
+
+	res := make([]int, len(bxs))
+
+	for i := range bxs {
+
+		l, u := bxs[i].Limits()
+
+		for j := i + 1; j < len(bxs); j++ {
+
+			if lower, upper := bxs[j].Limits(); intersects(l, u, lower, upper) {
+				res[i]++
+				res[j]++
+			}
+
+		}
+
+	}
+
+	return res
+
+}
+
+func TestOverlapDegreesMatchesBruteForce(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(82))
+	bxs := randomBoxes(rng, 1000, 100, 10)
+
+	// copies of a few boxes, collapsed under WithDedup
+	for _, i := range []int{3, 3, 500} {
+
+		l, u := bxs[i].Limits()
+		bxs = append(bxs, &testBox{[]float64{l[0], l[1]}, []float64{u[0], u[1]}})
+
+	}
+
+	want := bruteDegrees(bxs)
+
+	for _, opts := range [][]Option{{}, {WithLeafSize(8)}, {WithDedup()}} {
+
+		if got := NewBOXTree(bxs, opts...).OverlapDegrees(); !equalInts(got, want) {
+
+			for i := range want {
+
+				if got[i] != want[i] {
+					t.Fatalf("OverlapDegrees with %d options: box %d has degree %d, want %d", len(opts), i, got[i], want[i])
+				}
+
+			}
+
+			t.Fatalf("OverlapDegrees with %d options: %d degrees, want %d", len(opts), len(got), len(want))
+
+		}
+
+	}
+
+}
+
+func BenchmarkOverlapDegrees(b *testing.B) {
+
+	rng := rand.New(rand.NewSource(4))
+	bxs := randomBoxes(rng, 100000, 1000, 10)
+	tree := NewBOXTree(bxs)
+
+	b.Run("Tree", func(b *testing.B) {
+
+		for i := 0; i < b.N; i++ {
+			tree.OverlapDegrees()
+		}
+
+	})
+
+	// the all-pairs count, quadratic in the number of boxes
+	b.Run("Naive", func(b *testing.B) {
+
+		for i := 0; i < b.N; i++ {
+			bruteDegrees(bxs)
+		}
+
+	})
+
+}
+
+func TestFirstHitMatchesOverlaps(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(83))
+
+	for _, lf := range []int{0, 8} {
+
+		bxs := randomBoxes(rng, 1000, 100, 10)
+		tree := NewBOXTree(bxs, WithLeafSize(lf))
+		points := make([][]float64, 500)
+
+		for i := range points {
+			points[i] = []float64{rng.Float64() * 110, rng.Float64() * 110}
+		}
+
+		hits := tree.FirstHit(points)
+
+		if len(hits) != len(points) {
+			t.Fatalf("leaf %d: FirstHit returned %d results for %d points", lf, len(hits), len(points))
+		}
+
+		for i, vals := range points {
+
+			want := bruteOverlaps(bxs, vals)
+
+			if len(want) == 0 {
+
+				if hits[i] != -1 {
+					t.Fatalf("leaf %d: FirstHit(%v) = %d, want -1", lf, vals, hits[i])
+				}
+
+				continue
+
+			}
+
+			if hits[i] < 0 {
+				t.Fatalf("leaf %d: FirstHit(%v) = -1, want one of %v", lf, vals, want)
+			}
+
+			if l, u := bxs[hits[i]].Limits(); !within(l, u, vals) {
+				t.Fatalf("leaf %d: FirstHit(%v) = %d, want one of %v", lf, vals, hits[i], want)
+			}
+
+		}
+
+	}
+
+	if hits := NewBOXTree(nil).FirstHit([][]float64{{0, 0}}); !equalInts(hits, []int{-1}) {
+		t.Fatalf("FirstHit on empty tree = %v, want [-1]", hits)
+	}
+
+}
+
+func TestScanlineCoverageMergeAndGap(t *testing.T) {
+
+	tree := NewBOXTree([]Box{
+		&testBox{[]float64{0, 0}, []float64{4, 2}},
+		&testBox{[]float64{3, 1}, []float64{6, 3}},
+		&testBox{[]float64{8, 0}, []float64{10, 2}},
+		&testBox{[]float64{6, 2.5}, []float64{7, 4}},
+	})
+
+	for _, tc := range []struct {
+		y      float64
+		xRange [2]float64
+		want   [][2]float64
+	}{
+		// boxes 0 and 1 merge into one interval, box 2 is past a gap
+		{1.5, [2]float64{-1, 12}, [][2]float64{{0, 6}, {8, 10}}},
+		{0.5, [2]float64{-1, 12}, [][2]float64{{0, 4}, {8, 10}}},
+		// boxes 1 and 3 only touch at x = 6
+		{2.75, [2]float64{-1, 12}, [][2]float64{{3, 7}}},
+		{1.5, [2]float64{1, 9}, [][2]float64{{1, 6}, {8, 9}}},
+		{1.5, [2]float64{4.5, 7}, [][2]float64{{4.5, 6}}},
+		{1.5, [2]float64{6.5, 7.5}, [][2]float64{}},
+		{1.5, [2]float64{10, 12}, [][2]float64{}},
+		{5, [2]float64{-1, 12}, [][2]float64{}},
+	} {
+
+		got := tree.ScanlineCoverage(tc.y, tc.xRange)
+
+		if len(got) != len(tc.want) {
+			t.Fatalf("ScanlineCoverage(%v, %v) = %v, want %v", tc.y, tc.xRange, got, tc.want)
+		}
+
+		for i := range got {
+
+			if got[i] != tc.want[i] {
+				t.Fatalf("ScanlineCoverage(%v, %v) = %v, want %v", tc.y, tc.xRange, got, tc.want)
+			}
+
+		}
+
+	}
+
+}
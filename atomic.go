@@ -0,0 +1,62 @@
+// MIT License
+//
+// Copyright (c) 2022 geozelot (André Siefken)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package boxtree
+
+import (
+	"sync/atomic"
+)
+
+// Atomic is the hot swap holder for periodically reloaded trees;
+// lets queries Load the current tree while a freshly built one is swapped in via Store, without locking.
+//
+// The zero value holds no tree. Trees replaced by Store stay valid for queries already holding a reference to them,
+// so in-flight queries never observe a partially built tree; build the new tree completely before storing it.
+type Atomic struct {
+	val atomic.Value
+}
+
+// NewAtomic is the Atomic initialization function; holds the given tree, which may be nil.
+func NewAtomic(boT *BOXTree) *Atomic {
+
+	aT := Atomic{}
+	aT.val.Store(boT)
+
+	return &aT
+
+}
+
+// Load returns the current tree, or nil if none has been stored.
+func (aT *Atomic) Load() *BOXTree {
+
+	boT, _ := aT.val.Load().(*BOXTree)
+
+	return boT
+
+}
+
+// Store replaces the current tree with the given one, which must not be modified afterwards.
+func (aT *Atomic) Store(boT *BOXTree) {
+
+	aT.val.Store(boT)
+
+}
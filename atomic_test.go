@@ -0,0 +1,55 @@
+package boxtree
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func TestAtomicSwap(t *testing.T) {
+
+	if (&Atomic{}).Load() != nil || NewAtomic(nil).Load() != nil {
+		t.Fatal("empty Atomic holds a tree")
+	}
+
+	rng := rand.New(rand.NewSource(32))
+	a, b := NewBOXTree(randomBoxes(rng, 100, 100, 10)), NewBOXTree(randomBoxes(rng, 200, 100, 10))
+	at := NewAtomic(a)
+
+	var wg sync.WaitGroup
+
+	for g := 0; g < 4; g++ {
+
+		wg.Add(1)
+
+		go func() {
+
+			defer wg.Done()
+
+			for i := 0; i < 1000; i++ {
+
+				if n := at.Load().Len(); n != 100 && n != 200 {
+					t.Errorf("Load returned a tree of %d boxes", n)
+					return
+				}
+
+			}
+
+		}()
+
+	}
+
+	for i := 0; i < 100; i++ {
+
+		at.Store(b)
+		at.Store(a)
+
+	}
+
+	wg.Wait()
+
+	if at.Store(nil); at.Load() != nil {
+		t.Fatal("Load after Store(nil) holds a tree")
+	}
+
+}
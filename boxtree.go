@@ -24,8 +24,11 @@
 package boxtree
 
 import (
+	"fmt"
 	"math"
 	"math/rand"
+	"sync"
+	"sync/atomic"
 )
 
 // Box is the main interface expected by NewBOXTree(); requires Limits method to access box limits.
@@ -38,28 +41,516 @@ type Box interface {
 type BOXTree struct {
 	idxs []int
 	lmts [][]float64
+	ags  []float64
+	size int
+
+	dedup     bool
+	dups      [][]int
+	presorted bool
+	columnar  bool
+	cols      [4][]float64
+	period    []float64
+	tracer    Tracer
+	depth     int
+	lbls      []string
+	flat      bool
+	ax0       int
+	store     []Box
+	leaf      int
+	alloc     func(n int) []float64
+	allocInt  func(n int) []int
+	areas     bool
+	ars       []float64
+	wts       []float64
+	wmx       []float64
+	progress  func(done, total int)
+	upds      int
+	rebuild   float64
+	unbounded bool
+	cow       *uint32
+}
+
+// scanSize is the maximum number of boxes scanned linearly instead of traversed on a columnar tree.
+const scanSize = 64
+
+// stackSize is the capacity of the traversal stacks, holding one frame of three values per level of the deepest possible tree.
+const stackSize = 3 * 65
+
+// buffer is the internal query scratch space;
+// holds the result collector and traversal limits, recycled across queries via buffers.
+type buffer struct {
+	res  []int
+	lim  int
+	err  error
+	hook func(lb, rb, pos, ax int, dec TraceDecision)
+}
+
+// buffers is the internal pool of query scratch spaces.
+var buffers = sync.Pool{
+	New: func() interface{} {
+		return &buffer{}
+	},
 }
 
 // buildTree is the internal tree construction function;
-// creates, sorts and augments nodes into Slices.
+// creates, sorts and augments nodes into Slices, reusing previously allocated capacity.
+//
+// Nodes hold references to their lower and upper limits only; the augmented limits of all nodes are kept
+// in a single dense Slice of two values per node position.
 func (boT *BOXTree) buildTree(bxs []Box) {
 
-	boT.idxs = make([]int, len(bxs))
-	boT.lmts = make([][]float64, 3*len(bxs))
+	boT.detach(false)
+
+	if boT.cow == nil {
+		boT.cow = new(uint32)
+	}
+
+	if cap(boT.idxs) < len(bxs) {
+		boT.idxs = boT.ints(len(bxs))
+	} else {
+		boT.idxs = boT.idxs[:len(bxs)]
+	}
+
+	if cap(boT.lmts) < 2*len(bxs) {
+		boT.lmts = make([][]float64, 2*len(bxs))
+	} else {
+		boT.lmts = boT.lmts[:2*len(bxs)]
+	}
+
+	var seen, grps map[[4]float64]int
+
+	// collapsing boxes would shift presorted input out of its layout
+	dedup := boT.dedup && !boT.presorted
+	boT.dups = nil
+
+	if dedup {
+
+		seen, grps = map[[4]float64]int{}, map[[4]float64]int{}
+		boT.dups = [][]int{}
+
+	}
+
+	n := 0
+	boT.size = len(bxs)
 
 	for i, v := range bxs {
 
-		boT.idxs[i] = i
 		l, u := v.Limits()
 
-		boT.lmts[3*i] = l
-		boT.lmts[3*i+1] = u
-		boT.lmts[3*i+2] = []float64{0}
+		if boT.unbounded {
+			l, u = unbound(l, u)
+		}
+
+		if dedup {
+
+			k := [4]float64{l[0], l[1], u[0], u[1]}
+
+			if f, ok := seen[k]; ok {
+
+				if g, ok := grps[k]; ok {
+					boT.dups[g] = append(boT.dups[g], i)
+				} else {
+					grps[k] = len(boT.dups)
+					boT.dups = append(boT.dups, []int{f, i})
+				}
+
+				continue
+
+			}
+
+			seen[k] = i
+
+		}
+
+		boT.idxs[n] = i
+
+		boT.lmts[2*n] = l
+		boT.lmts[2*n+1] = u
+
+		n++
+
+	}
+
+	boT.idxs = boT.idxs[:n]
+	boT.lmts = boT.lmts[:2*n]
+
+	if cap(boT.ags) < 2*n {
+		boT.ags = boT.floats(2 * n)
+	} else {
+		boT.ags = boT.ags[:2*n]
+	}
+
+	boT.flat, boT.ax0, boT.upds = false, 0, 0
+	pr := progress{fn: boT.progress, total: 2 * n}
+
+	if !boT.presorted {
+
+		boT.flatten()
+		pr.sort(boT.lmts, boT.idxs, boT.ax0, boT.step(), boT.leaf)
+
+	} else {
+		pr.add(n)
+	}
+
+	pr.augment(boT.lmts, boT.ags, boT.idxs, boT.ax0, boT.step(), boT.leaf)
+
+	if boT.columnar {
+		boT.columns()
+	}
+
+	if boT.areas {
+		boT.measure()
+	}
+
+	if boT.wts != nil {
+		boT.weigh()
+	}
+
+}
+
+// unbound is an internal utility function, replacing NaN lower limits by -Inf and NaN upper limits by +Inf;
+// returns the given Slices if neither holds NaN, otherwise copies.
+func unbound(l, u []float64) ([]float64, []float64) {
+
+	if !math.IsNaN(l[0]) && !math.IsNaN(l[1]) && !math.IsNaN(u[0]) && !math.IsNaN(u[1]) {
+		return l, u
+	}
+
+	l, u = []float64{l[0], l[1]}, []float64{u[0], u[1]}
+
+	for ax := 0; ax < 2; ax++ {
+
+		if math.IsNaN(l[ax]) {
+			l[ax] = math.Inf(-1)
+		}
+
+		if math.IsNaN(u[ax]) {
+			u[ax] = math.Inf(1)
+		}
+
+	}
+
+	return l, u
+
+}
+
+// ints and floats are the internal allocation functions for tree storage; use the functions set via WithAllocator, if any.
+func (boT *BOXTree) ints(n int) []int {
+
+	if boT.allocInt != nil {
+		return boT.allocInt(n)[:n]
+	}
+
+	return make([]int, n)
+
+}
+
+func (boT *BOXTree) floats(n int) []float64 {
+
+	if boT.alloc != nil {
+		return boT.alloc(n)[:n]
+	}
+
+	return make([]float64, n)
+
+}
+
+// measure is the internal area precomputation function; stores the area of each box by original index.
+func (boT *BOXTree) measure() {
+
+	if cap(boT.ars) < boT.size {
+		boT.ars = make([]float64, boT.size)
+	} else {
+		boT.ars = boT.ars[:boT.size]
+	}
+
+	for i, idx := range boT.idxs {
+
+		l, u := boT.lmts[2*i], boT.lmts[2*i+1]
+		boT.ars[idx] = (u[0] - l[0]) * (u[1] - l[1])
+
+	}
+
+	for _, grp := range boT.dups {
+
+		for _, idx := range grp[1:] {
+			boT.ars[idx] = boT.ars[grp[0]]
+		}
+
+	}
+
+}
+
+// area is the internal node area function; returns the area of the box at node position cn, precomputed if available.
+func (boT *BOXTree) area(cn int) float64 {
+
+	if boT.areas {
+		return boT.ars[boT.idxs[cn]]
+	}
+
+	l, u := boT.lmts[2*cn], boT.lmts[2*cn+1]
+
+	return (u[0] - l[0]) * (u[1] - l[1])
+
+}
+
+// flatten is the internal degenerate axis detection function;
+// if all boxes share identical limits on one axis, levels the tree on the other axis only, as the flat one cannot discriminate.
+func (boT *BOXTree) flatten() {
+
+	if len(boT.idxs) < 2 {
+		return
+	}
+
+	for f := 1; f >= 0; f-- {
+
+		eq := true
+
+		for i := range boT.idxs {
+
+			if boT.lmts[2*i][f] != boT.lmts[0][f] || boT.lmts[2*i+1][f] != boT.lmts[1][f] {
+				eq = false
+				break
+			}
+
+		}
+
+		if eq {
+			boT.flat, boT.ax0 = true, (f+1)%2
+			return
+		}
+
+	}
+
+}
+
+// step is an internal utility function, returning the axis increment between tree levels (0 for trees leveled on a single axis).
+func (boT *BOXTree) step() int {
+
+	if boT.flat {
+		return 0
+	}
+
+	return 1
+
+}
+
+// columns is the internal layout function for WithColumnar;
+// copies the box limits into one Slice per axis and bound, in node order.
+func (boT *BOXTree) columns() {
+
+	for c := range boT.cols {
+
+		if cap(boT.cols[c]) < len(boT.idxs) {
+			boT.cols[c] = make([]float64, len(boT.idxs))
+		} else {
+			boT.cols[c] = boT.cols[c][:len(boT.idxs)]
+		}
+
+	}
+
+	for i := range boT.idxs {
+
+		boT.cols[0][i], boT.cols[1][i] = boT.lmts[2*i][0], boT.lmts[2*i][1]
+		boT.cols[2][i], boT.cols[3][i] = boT.lmts[2*i+1][0], boT.lmts[2*i+1][1]
+
+	}
+
+}
+
+// Snapshot is the lightweight copy function;
+// returns a handle sharing all internal Slices with the tree, costing only the handle itself.
+//
+// The Slices are marked shared through a flag held with them, not in the handle: the first Rebuild, Reset, UpdateLimits
+// or Reaugment on any handle sharing them moves that handle onto fresh Slices (copy-on-write) instead of writing to the shared ones,
+// so the others stay valid. Snapshot does not modify the tree and may be called concurrently with queries and other Snapshot calls.
+func (boT *BOXTree) Snapshot() *BOXTree {
+
+	if boT.cow != nil {
+		atomic.StoreUint32(boT.cow, 1)
+	}
+
+	snp := *boT
+
+	return &snp
+
+}
+
+// detach is the internal copy-on-write function behind Snapshot;
+// if the tree shares its Slices, drops them ahead of a rebuild or, with keep, copies those modified in place by updates.
+func (boT *BOXTree) detach(keep bool) {
+
+	if boT.cow == nil || atomic.LoadUint32(boT.cow) == 0 {
+		return
+	}
+
+	boT.cow = new(uint32)
+
+	if !keep {
+
+		boT.idxs, boT.lmts, boT.ags = nil, nil, nil
+		boT.ars, boT.wmx, boT.cols = nil, nil, [4][]float64{}
+
+		if boT.store != nil {
+			boT.store = []Box{}
+		}
+
+		return
+
+	}
+
+	boT.lmts = append([][]float64(nil), boT.lmts...)
+	boT.ags = append(boT.floats(len(boT.ags))[:0], boT.ags...)
+	boT.ars = append([]float64(nil), boT.ars...)
+
+	for c := range boT.cols {
+		boT.cols[c] = append([]float64(nil), boT.cols[c]...)
+	}
+
+}
+
+// Reset empties the tree for reuse;
+// truncates all Slices to zero length, keeping their capacity for the next Rebuild.
+func (boT *BOXTree) Reset() {
+
+	boT.detach(false)
+
+	boT.idxs = boT.idxs[:0]
+	boT.lmts = boT.lmts[:0]
+	boT.ags = boT.ags[:0]
+	boT.size = 0
+	boT.dups = nil
+	boT.lbls = nil
+	boT.wts, boT.wmx = nil, nil
+	boT.store = boT.store[:0]
+	boT.ars = boT.ars[:0]
+
+	for c := range boT.cols {
+		boT.cols[c] = boT.cols[c][:0]
+	}
+
+}
+
+// Rebuild refills the tree;
+// recreates the tree from the given Slice of Box, reusing the capacity of the current one.
+func (boT *BOXTree) Rebuild(bxs []Box) {
+
+	boT.buildTree(bxs)
+
+	if boT.store != nil {
+		boT.store = append(boT.store[:0], bxs...)
+	}
+
+}
+
+// Len is the size accessor; returns the number of boxes stored in the tree.
+func (boT *BOXTree) Len() int {
+
+	return len(boT.idxs)
+
+}
+
+// Area is the box area accessor; returns the area of the box with the given original index, as stored in the tree.
+//
+// With WithPrecomputedAreas this is a lookup, otherwise the box is searched for in O(n); unknown indices yield NaN.
+func (boT *BOXTree) Area(idx int) float64 {
+
+	if boT.areas {
+		return boT.ars[idx]
+	}
+
+	for _, grp := range boT.dups {
+
+		for _, v := range grp[1:] {
+
+			if v == idx {
+				idx = grp[0]
+			}
+
+		}
+
+	}
+
+	for i, v := range boT.idxs {
+
+		if v == idx {
+			return boT.area(i)
+		}
+
+	}
+
+	return math.NaN()
+
+}
+
+// Positions is the low-level layout introspection function;
+// returns, per original box index, the position of its node in the flat tree layout (the inverse of the internal index Slice).
+//
+// Boxes collapsed by WithDedup map to the node of their group. Positions change with every build.
+func (boT *BOXTree) Positions() []int {
+
+	pos := make([]int, boT.size)
+
+	for i, idx := range boT.idxs {
+		pos[idx] = i
+	}
+
+	for _, grp := range boT.dups {
+
+		for _, idx := range grp[1:] {
+			pos[idx] = pos[grp[0]]
+		}
+
+	}
+
+	return pos
+
+}
+
+// Duplicates reports the boxes collapsed by WithDedup;
+// returns groups of original indices that shared exactly equal limits, each in ascending order, with the index returned by queries first.
+func (boT *BOXTree) Duplicates() [][]int {
+
+	if boT.dups == nil {
+		return [][]int{}
+	}
+
+	return boT.dups
+
+}
+
+// IsConsistent is the structural safety check for loaded trees;
+// reports whether the internal Slices have matching lengths, all limits have two dimensions and all indices are within range.
+//
+// Much cheaper than Validate, which also checks order and augmented limits; trees passing it do not panic on queries.
+func (boT *BOXTree) IsConsistent() bool {
+
+	if len(boT.lmts) != 2*len(boT.idxs) || len(boT.ags) != 2*len(boT.idxs) || boT.size < len(boT.idxs) {
+		return false
+	}
+
+	for i, idx := range boT.idxs {
+
+		if idx < 0 || idx >= boT.size {
+			return false
+		}
+
+		if len(boT.lmts[2*i]) < 2 || len(boT.lmts[2*i+1]) < 2 {
+			return false
+		}
 
 	}
 
-	sort(boT.lmts, boT.idxs, 0)
-	augment(boT.lmts, boT.idxs, 0)
+	return true
+
+}
+
+// Validate is the structural self-check;
+// verifies that every node splits its range by lower limit and that all augmented limits bound their subtrees,
+// returning an error describing the first violation found.
+func (boT *BOXTree) Validate() error {
+
+	return validate(boT.lmts, boT.ags, boT.idxs, boT.ax0, boT.step(), boT.leaf, 0)
 
 }
 
@@ -67,125 +558,505 @@ func (boT *BOXTree) buildTree(bxs []Box) {
 // traverses the tree and collects boxes that overlap with the given values.
 func (boT *BOXTree) Overlaps(vals []float64) []int {
 
-	stk := []int{0, len(boT.idxs) - 1, 0}
+	if boT.tracer != nil {
+
+		boT.tracer.OnQueryStart(vals)
+		res := boT.overlaps(vals)
+		boT.tracer.OnQueryEnd(len(res))
+
+		return res
+
+	}
+
+	return boT.overlaps(vals)
+
+}
+
+// overlaps is the internal dispatch function for Overlaps;
+// selects the query strategy matching the tree configuration.
+func (boT *BOXTree) overlaps(vals []float64) []int {
+
+	if boT.scans() {
+		return boT.scan(vals)
+	}
+
+	return boT.OverlapsCustom(vals, within)
+
+}
+
+// scans reports whether point queries scan the limit columns instead of traversing, on small columnar trees without wrapping.
+func (boT *BOXTree) scans() bool {
+
+	return boT.columnar && boT.period == nil && len(boT.idxs) <= scanSize
+
+}
+
+// scan is the internal linear query function for small columnar trees;
+// compares the values against the limit columns of all boxes in a single pass.
+func (boT *BOXTree) scan(vals []float64) []int {
+
 	res := []int{}
 
-	for len(stk) > 0 {
+	boT.scanBox(vals, vals, func(cn int) {
+		res = append(res, boT.idxs[cn])
+	})
 
-		ax := stk[len(stk)-1]
-		stk = stk[:len(stk)-1]
-		rb := stk[len(stk)-1]
-		stk = stk[:len(stk)-1]
-		lb := stk[len(stk)-1]
-		stk = stk[:len(stk)-1]
+	return res
 
-		if lb == rb+1 {
-			continue
+}
+
+// scanBox is the internal linear box-vs-box search over the limit columns;
+// passes the node position of each box intersecting the given limits (inclusive) to fn, in node order.
+func (boT *BOXTree) scanBox(lower, upper []float64, fn func(cn int)) {
+
+	x0, y0, x1, y1 := boT.cols[0], boT.cols[1], boT.cols[2], boT.cols[3]
+
+	for i := range x0 {
+
+		if x0[i] <= upper[0] && lower[0] <= x1[i] && y0[i] <= upper[1] && lower[1] <= y1[i] {
+			fn(i)
 		}
 
-		cn := int(math.Ceil(float64(lb+rb) / 2.0))
-		nm := boT.lmts[3*cn+2][0]
+	}
+
+}
+
+// OverlapsCustom is the predicate variant of Overlaps;
+// traverses the tree and collects boxes for which hit accepts the given values.
+//
+// Pruning still relies on the augmented (inclusive) box limits, so hit is only consulted for boxes
+// that could overlap the values in the standard sense; predicates more permissive than that may miss matches.
+func (boT *BOXTree) OverlapsCustom(vals []float64, hit func(l, u, vals []float64) bool) []int {
+
+	buf := buffers.Get().(*buffer)
+	res := buf.res[:0]
 
-		_ax := (ax + 1) % 2
+	boT.walk(buf, vals, hit, func(cn int) bool {
 
-		if vals[ax] <= nm {
+		res = append(res, boT.idxs[cn])
+		return true
+
+	})
+
+	out := make([]int, len(res))
+	copy(out, res)
+
+	buf.res = res[:0]
+	buffers.Put(buf)
+
+	return out
+
+}
 
-			stk = append(stk, lb)
-			stk = append(stk, cn-1)
-			stk = append(stk, _ax)
+// traverse is the internal query function for result variants;
+// walks the tree with a pooled buffer, passing the node position of each box overlapping the given values to fn.
+func (boT *BOXTree) traverse(vals []float64, fn func(cn int) bool) {
 
+	buf := buffers.Get().(*buffer)
+	boT.walk(buf, vals, within, fn)
+	buffers.Put(buf)
+
+}
+
+// traverseAt is the shift aware variant of traverse, for result variants measuring boxes against the values;
+// also passes the values each box matched at to fn, shifted by the period on trees built by NewBOXTreeWrapped.
+func (boT *BOXTree) traverseAt(vals []float64, fn func(cn int, at []float64) bool) {
+
+	buf := buffers.Get().(*buffer)
+
+	if boT.period != nil {
+
+		boT.wrapWalk(buf, vals, within, fn)
+
+	} else {
+
+		boT.probe(buf, vals, within, func(cn int) bool {
+			return fn(cn, vals)
+		})
+
+	}
+
+	buffers.Put(buf)
+
+}
+
+// walk is the internal tree traversal function;
+// passes the node position of each box accepted by hit to fn, stopping early if fn returns false.
+//
+// With buf.hook set, also reports the decisions taken for each visited range [lb, rb] with split axis ax,
+// and for each box hit within a scanned bucket, under the position of the respective node.
+// Trees built by NewBOXTreeWrapped are walked once per shift of the values, see wrapWalk.
+func (boT *BOXTree) walk(buf *buffer, vals []float64, hit func(l, u, vals []float64) bool, fn func(cn int) bool) {
+
+	if boT.period != nil {
+
+		boT.wrapWalk(buf, vals, hit, func(cn int, _ []float64) bool {
+			return fn(cn)
+		})
+
+		return
+
+	}
+
+	boT.probe(buf, vals, hit, fn)
+
+}
+
+// probe is the single pass traversal behind walk, for the given values as they are.
+func (boT *BOXTree) probe(buf *buffer, vals []float64, hit func(l, u, vals []float64) bool, fn func(cn int) bool) {
+
+	descend(buf, len(boT.idxs), boT.ax0, boT.step(), 2, func(lb, rb, cn, ax int) (left, right, ok bool) {
+
+		ag := boT.ags[2*cn : 2*cn+2]
+
+		if boT.tracer != nil {
+			boT.tracer.OnNodeVisit(cn)
 		}
 
-		l := boT.lmts[3*cn]
+		if rb-lb < boT.leaf {
+
+			if vals[ax] < ag[1] || ag[0] < vals[ax] {
+
+				if buf.hook != nil {
+					buf.hook(lb, rb, cn, ax, TracePrune)
+				}
+
+				return false, false, true
+
+			}
+
+			if buf.hook != nil {
+				buf.hook(lb, rb, cn, ax, TraceBucket)
+			}
+
+			for i := lb; i <= rb; i++ {
 
-		if l[ax] <= vals[ax] {
+				if hit(boT.lmts[2*i], boT.lmts[2*i+1], vals) {
 
-			stk = append(stk, cn+1)
-			stk = append(stk, rb)
-			stk = append(stk, _ax)
+					if buf.hook != nil {
+						buf.hook(lb, rb, i, ax, TraceHit)
+					}
 
-			u := boT.lmts[3*cn+1]
+					if !fn(i) {
+						return false, false, false
+					}
+
+				}
 
-			if vals[ax] <= u[ax] && vals[_ax] <= u[_ax] && l[_ax] <= vals[_ax] {
-				res = append(res, boT.idxs[cn])
 			}
 
+			return false, false, true
+
+		}
+
+		l := boT.lmts[2*cn]
+		left, right = vals[ax] <= ag[0], l[ax] <= vals[ax]
+		ht := right && hit(l, boT.lmts[2*cn+1], vals)
+
+		if buf.hook != nil {
+			buf.hook(lb, rb, cn, ax, decide(lb, rb, cn, left, right, ht))
+		}
+
+		return left, right, !ht || fn(cn)
+
+	})
+
+}
+
+// traverseBox is the internal query function for box-vs-box variants;
+// walks the tree with a pooled buffer, passing the node position of each box intersecting the given limits to fn.
+func (boT *BOXTree) traverseBox(lower, upper []float64, fn func(cn int) bool) {
+
+	buf := buffers.Get().(*buffer)
+	boT.walkBox(buf, lower, upper, intersects, fn)
+	buffers.Put(buf)
+
+}
+
+// walkBox is the internal box-vs-box traversal function;
+// prunes subtrees by both augmented limits and passes the node position of each box accepted by hit to fn, stopping early if fn returns false.
+func (boT *BOXTree) walkBox(buf *buffer, lower, upper []float64, hit func(l, u, lower, upper []float64) bool, fn func(cn int) bool) {
+
+	descend(buf, len(boT.idxs), boT.ax0, boT.step(), 2, func(lb, rb, cn, ax int) (left, right, ok bool) {
+
+		ag := boT.ags[2*cn : 2*cn+2]
+
+		if upper[ax] < ag[1] {
+			return false, false, true
+		}
+
+		if rb-lb < boT.leaf {
+
+			if ag[0] < lower[ax] {
+				return false, false, true
+			}
+
+			for i := lb; i <= rb; i++ {
+
+				if hit(boT.lmts[2*i], boT.lmts[2*i+1], lower, upper) && !fn(i) {
+					return false, false, false
+				}
+
+			}
+
+			return false, false, true
+
+		}
+
+		l := boT.lmts[2*cn]
+		left, right = lower[ax] <= ag[0], l[ax] <= upper[ax]
+
+		return left, right, !right || !hit(l, boT.lmts[2*cn+1], lower, upper) || fn(cn)
+
+	})
+
+}
+
+// descend is the internal traversal engine shared by all tree queries;
+// pops ranges off an explicit stack, starting with the full range of n nodes split on axis ax0, and passes each non-empty one
+// along with its midpoint node cn and split axis ax to visit.
+//
+// visit returns whether to descend into the left and right half of the range, split on the axis advanced by st modulo d,
+// or aborts the traversal by returning false for ok. Leaf buckets are never split, so visit scans them itself.
+// With buf.lim set, the traversal also aborts, recording ErrMaxDepth in buf.err, once the stack outgrows it; buf may be nil.
+func descend(buf *buffer, n, ax0, st, d int, visit func(lb, rb, cn, ax int) (left, right, ok bool)) {
+
+	var arr [stackSize]int
+	stk := append(arr[:0], 0, n-1, ax0)
+
+	for len(stk) > 0 {
+
+		if buf != nil && buf.lim > 0 && len(stk) > buf.lim {
+			buf.err = ErrMaxDepth
+			return
+		}
+
+		lb, rb, ax := stk[len(stk)-3], stk[len(stk)-2], stk[len(stk)-1]
+		stk = stk[:len(stk)-3]
+
+		if lb > rb {
+			continue
+		}
+
+		cn := int(math.Ceil(float64(lb+rb) / 2.0))
+		left, right, ok := visit(lb, rb, cn, ax)
+
+		if !ok {
+			return
+		}
+
+		if left && lb < cn {
+			stk = append(stk, lb, cn-1, (ax+st)%d)
+		}
+
+		if right && cn < rb {
+			stk = append(stk, cn+1, rb, (ax+st)%d)
 		}
 
 	}
 
-	return res
+}
+
+// intersects is the standard box-vs-box predicate, checking whether the given limits share at least one point (inclusive).
+func intersects(l, u, lower, upper []float64) bool {
+
+	return l[0] <= upper[0] && lower[0] <= u[0] && l[1] <= upper[1] && lower[1] <= u[1]
+
+}
+
+// within is the standard overlap predicate, checking whether the values lie inside the given limits (inclusive).
+func within(l, u, vals []float64) bool {
+
+	return l[0] <= vals[0] && vals[0] <= u[0] && l[1] <= vals[1] && vals[1] <= u[1]
 
 }
 
 // NewBOXTree is the main initialization function;
-// creates the tree from the given Slice of Box.
-func NewBOXTree(bxs []Box) *BOXTree {
+// creates the tree from the given Slice of Box, configured by the given Options.
+func NewBOXTree(bxs []Box, opts ...Option) *BOXTree {
 
 	boT := BOXTree{}
+
+	for _, opt := range opts {
+		opt(&boT)
+	}
+
 	boT.buildTree(bxs)
 
 	return &boT
 
 }
 
-// augment is an internal utility function, adding maximum value of all child nodes to the current node.
-func augment(lmts [][]float64, idxs []int, ax int) {
+// Overlaps is the one-shot search function;
+// collects the boxes of the given Slice of Box that overlap with the given values, without keeping a tree, e.g.
+//
+//	idxs := boxtree.Overlaps(bxs, []float64{3.2, 6.3})
+//
+// Returns the same boxes as NewBOXTree(bxs).Overlaps(vals), in ascending index order. As a single query does not pay off a build,
+// it scans all boxes in O(n) instead; for repeated queries on the same boxes, build the tree once.
+func Overlaps(bxs []Box, vals []float64) []int {
+
+	res := []int{}
+
+	for i, v := range bxs {
+
+		if l, u := v.Limits(); within(l, u, vals) {
+			res = append(res, i)
+		}
+
+	}
+
+	return res
+
+}
+
+// augment is an internal utility function, storing maximum upper and minimum lower value of all child nodes in the augmented limits of the current node;
+// ranges of up to lf nodes form a single leaf bucket, augmented on its midpoint node only.
+func augment(lmts [][]float64, ags []float64, idxs []int, ax int, st int, lf int) {
 
 	if len(idxs) < 1 {
 		return
 	}
 
-	max := 0.0
+	max, min := bounds(lmts, len(idxs), ax)
 
-	for idx := range idxs {
+	r := len(idxs) >> 1
+
+	ags[2*r] = max
+	ags[2*r+1] = min
 
-		if lmts[3*idx+1][ax] > max {
-			max = lmts[3*idx+1][ax]
+	if len(idxs) <= lf {
+		return
+	}
+
+	augment(lmts[:2*r], ags[:2*r], idxs[:r], (ax+st)%2, st, lf)
+	augment(lmts[2*r+2:], ags[2*r+2:], idxs[r+1:], (ax+st)%2, st, lf)
+
+}
+
+// bounds is an internal utility function, finding the maximum upper and minimum lower value of the first n nodes on axis ax.
+func bounds(lmts [][]float64, n int, ax int) (max, min float64) {
+
+	max, min = math.Inf(-1), math.Inf(1)
+
+	for idx := 0; idx < n; idx++ {
+
+		if lmts[2*idx+1][ax] > max {
+			max = lmts[2*idx+1][ax]
 		}
 
+		if lmts[2*idx][ax] < min {
+			min = lmts[2*idx][ax]
+		}
+
+	}
+
+	return max, min
+
+}
+
+// validate is an internal utility function, checking the ordering and augmentation of the current node and all child nodes.
+func validate(lmts [][]float64, ags []float64, idxs []int, ax int, st int, lf int, off int) error {
+
+	if len(idxs) < 1 {
+		return nil
 	}
 
 	r := len(idxs) >> 1
+	l, ag := lmts[2*r], ags[2*r:2*r+2]
 
-	lmts[3*r+2][0] = max
+	for idx := range idxs {
+
+		if len(idxs) > lf && (idx < r && lmts[2*idx][ax] > l[ax] || idx > r && lmts[2*idx][ax] < l[ax]) {
+			return fmt.Errorf("boxtree: node %d out of order with node %d on axis %d", off+idx, off+r, ax)
+		}
+
+		if lmts[2*idx+1][ax] > ag[0] || lmts[2*idx][ax] < ag[1] {
+			return fmt.Errorf("boxtree: node %d not bounded by augmented limits of node %d on axis %d", off+idx, off+r, ax)
+		}
+
+	}
 
-	augment(lmts[:3*r], idxs[:r], (ax+1)%2)
-	augment(lmts[3*r+3:], idxs[r+1:], (ax+1)%2)
+	if len(idxs) <= lf {
+		return nil
+	}
+
+	if err := validate(lmts[:2*r], ags[:2*r], idxs[:r], (ax+st)%2, st, lf, off); err != nil {
+		return err
+	}
+
+	return validate(lmts[2*r+2:], ags[2*r+2:], idxs[r+1:], (ax+st)%2, st, lf, off+r+1)
 
 }
 
-// sort is an internal utility function, sorting the tree by lowest limits using Random Pivot QuickSearch
-func sort(lmts [][]float64, idxs []int, ax int) {
+// sort is an internal utility function, ordering the tree by lowest limits using Random Pivot QuickSelect;
+// places the median of each range on its midpoint node, advancing the axis by st per level and leaving ranges of up to lf nodes unordered.
+func sort(lmts [][]float64, idxs []int, ax int, st int, lf int) {
 
-	if len(idxs) < 2 {
+	if len(idxs) < 2 || len(idxs) <= lf {
 		return
 	}
 
-	l, r := 0, len(idxs)-1
+	k := median(lmts, idxs, ax)
+
+	sort(lmts[:2*k], idxs[:k], (ax+st)%2, st, lf)
+	sort(lmts[2*k+2:], idxs[k+1:], (ax+st)%2, st, lf)
+
+}
 
-	p := rand.Int() % len(idxs)
+// median is an internal utility function, moving the box with the median lowest limit on ax to the midpoint of the range
+// and partitioning the others around it; returns the midpoint.
+func median(lmts [][]float64, idxs []int, ax int) int {
 
-	idxs[p], idxs[r] = idxs[r], idxs[p]
-	lmts[3*p], lmts[3*p+1], lmts[3*p+2], lmts[3*r], lmts[3*r+1], lmts[3*r+2] = lmts[3*r], lmts[3*r+1], lmts[3*r+2], lmts[3*p], lmts[3*p+1], lmts[3*p+2]
+	k := len(idxs) >> 1
+	lb, rb := 0, len(idxs)-1
 
-	for i := range idxs {
+	for lb < rb {
 
-		if lmts[3*i][ax] < lmts[3*r][ax] {
+		swap(lmts, idxs, lb+rand.Int()%(rb-lb+1), rb)
 
-			idxs[l], idxs[i] = idxs[i], idxs[l]
-			lmts[3*l], lmts[3*l+1], lmts[3*l+2], lmts[3*i], lmts[3*i+1], lmts[3*i+2] = lmts[3*i], lmts[3*i+1], lmts[3*i+2], lmts[3*l], lmts[3*l+1], lmts[3*l+2]
+		pv := lmts[2*rb][ax]
+		l, e := lb, lb
 
-			l++
+		for i := lb; i < rb; i++ {
+
+			if lmts[2*i][ax] < pv {
+
+				swap(lmts, idxs, i, e)
+				swap(lmts, idxs, e, l)
+
+				l++
+				e++
+
+			} else if lmts[2*i][ax] == pv {
+
+				swap(lmts, idxs, i, e)
+				e++
+
+			}
 
 		}
 
+		swap(lmts, idxs, e, rb)
+
+		if k < l {
+			rb = l - 1
+		} else if k > e {
+			lb = e + 1
+		} else {
+			break
+		}
+
 	}
 
-	idxs[l], idxs[r] = idxs[r], idxs[l]
-	lmts[3*l], lmts[3*l+1], lmts[3*l+2], lmts[3*r], lmts[3*r+1], lmts[3*r+2] = lmts[3*r], lmts[3*r+1], lmts[3*r+2], lmts[3*l], lmts[3*l+1], lmts[3*l+2]
+	return k
+
+}
+
+// swap is an internal utility function, exchanging two nodes along with their reference indices.
+func swap(lmts [][]float64, idxs []int, i, j int) {
 
-	sort(lmts[:3*l], idxs[:l], (ax+1)%2)
-	sort(lmts[3*l+3:], idxs[l+1:], (ax+1)%2)
+	idxs[i], idxs[j] = idxs[j], idxs[i]
+	lmts[2*i], lmts[2*i+1], lmts[2*j], lmts[2*j+1] = lmts[2*j], lmts[2*j+1], lmts[2*i], lmts[2*i+1]
 
 }
@@ -0,0 +1,543 @@
+package boxtree
+
+import (
+	"math/rand"
+	gosort "sort"
+	"sync"
+	"testing"
+)
+
+// testBox is a minimal Box implementation for tests.
+type testBox struct {
+	lower, upper []float64
+}
+
+func (tb *testBox) Limits() (Lower, Upper []float64) {
+	return tb.lower, tb.upper
+}
+
+// randomBoxes creates n boxes with lower limits in [0, span) and extents in [0, size).
+func randomBoxes(rng *rand.Rand, n int, span, size float64) []Box {
+
+	bxs := make([]Box, n)
+
+	for i := range bxs {
+
+		x, y := rng.Float64()*span, rng.Float64()*span
+		bxs[i] = &testBox{[]float64{x, y}, []float64{x + rng.Float64()*size, y + rng.Float64()*size}}
+
+	}
+
+	return bxs
+
+}
+
+// bruteOverlaps returns the ascending indices of all boxes containing vals (inclusive).
+func bruteOverlaps(bxs []Box, vals []float64) []int {
+
+	res := []int{}
+
+	for i, bx := range bxs {
+
+		l, u := bx.Limits()
+
+		if l[0] <= vals[0] && vals[0] <= u[0] && l[1] <= vals[1] && vals[1] <= u[1] {
+			res = append(res, i)
+		}
+
+	}
+
+	return res
+
+}
+
+// sorted returns an ascending copy of idxs.
+func sorted(idxs []int) []int {
+
+	res := append([]int{}, idxs...)
+	gosort.Ints(res)
+
+	return res
+
+}
+
+func equalInts(a, b []int) bool {
+
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+
+		if a[i] != b[i] {
+			return false
+		}
+
+	}
+
+	return true
+
+}
+
+func TestOverlapsMatchesBruteForce(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(1))
+
+	for _, n := range []int{0, 1, 2, 3, 7, 64, 1000} {
+
+		bxs := randomBoxes(rng, n, 100, 20)
+		tree := NewBOXTree(bxs)
+
+		for q := 0; q < 500; q++ {
+
+			vals := []float64{rng.Float64() * 120, rng.Float64() * 120}
+
+			if got, want := sorted(tree.Overlaps(vals)), bruteOverlaps(bxs, vals); !equalInts(got, want) {
+				t.Fatalf("n=%d, Overlaps(%v) = %v, want %v", n, vals, got, want)
+			}
+
+		}
+
+	}
+
+}
+
+func TestOverlapsDuplicateLimits(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(2))
+	bxs := make([]Box, 500)
+
+	for i := range bxs {
+
+		x, y := float64(rng.Intn(5)), float64(rng.Intn(5))
+		bxs[i] = &testBox{[]float64{x, y}, []float64{x + float64(rng.Intn(3)), y + float64(rng.Intn(3))}}
+
+	}
+
+	tree := NewBOXTree(bxs)
+
+	for x := 0.0; x <= 8; x += 0.5 {
+
+		for y := 0.0; y <= 8; y += 0.5 {
+
+			vals := []float64{x, y}
+
+			if got, want := sorted(tree.Overlaps(vals)), bruteOverlaps(bxs, vals); !equalInts(got, want) {
+				t.Fatalf("Overlaps(%v) = %v, want %v", vals, got, want)
+			}
+
+		}
+
+	}
+
+}
+
+func TestOverlapsOneShot(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(50))
+
+	for _, n := range []int{0, 1, 10, 1000} {
+
+		bxs := randomBoxes(rng, n, 100, 10)
+		tree := NewBOXTree(bxs)
+
+		for q := 0; q < 100; q++ {
+
+			vals := []float64{rng.Float64() * 110, rng.Float64() * 110}
+
+			if got, want := Overlaps(bxs, vals), sorted(tree.Overlaps(vals)); !equalInts(got, want) {
+				t.Fatalf("%d boxes, Overlaps(bxs, %v) = %v, want %v", n, vals, got, want)
+			}
+
+		}
+
+	}
+
+}
+
+func BenchmarkOverlaps(b *testing.B) {
+
+	rng := rand.New(rand.NewSource(3))
+	tree := NewBOXTree(randomBoxes(rng, 100000, 1000, 10))
+
+	qs := make([][]float64, 1024)
+
+	for i := range qs {
+		qs[i] = []float64{rng.Float64() * 1000, rng.Float64() * 1000}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		tree.Overlaps(qs[i%len(qs)])
+	}
+
+}
+
+func TestOverlapsStackAllocs(t *testing.T) {
+
+	if raceEnabled {
+		t.Skip("allocation counts are skewed by the race detector")
+	}
+
+	rng := rand.New(rand.NewSource(53))
+
+	for _, lf := range []int{0, 8} {
+
+		bxs := randomBoxes(rng, 100000, 1000, 10)
+		tree := NewBOXTree(bxs, WithLeafSize(lf))
+		hit, _ := bxs[0].Limits()
+		miss := []float64{-5, 500}
+
+		// the traversal stack lives in a fixed size array, so a query without results does not allocate at all
+		// and one with results only allocates the returned Slice
+		if n := testing.AllocsPerRun(100, func() { tree.Overlaps(miss) }); n != 0 {
+			t.Fatalf("leaf %d: Overlaps without results allocates %v times, want 0", lf, n)
+		}
+
+		if n := testing.AllocsPerRun(100, func() { tree.Overlaps(hit) }); n != 1 {
+			t.Fatalf("leaf %d: Overlaps with results allocates %v times, want 1", lf, n)
+		}
+
+		if n := testing.AllocsPerRun(100, func() { tree.Nearest(hit) }); n != 0 {
+			t.Fatalf("leaf %d: Nearest allocates %v times, want 0", lf, n)
+		}
+
+	}
+
+}
+
+func TestOverlapsTileMatchesBruteForce(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(7))
+
+	for _, lf := range []int{0, 8} {
+
+		bxs := randomBoxes(rng, 1000, 100, 10)
+		tree := NewBOXTree(bxs, WithLeafSize(lf))
+
+		for q := 0; q < 300; q++ {
+
+			x, y := rng.Float64()*110, rng.Float64()*110
+			tb := [2][2]float64{{x, y}, {x + rng.Float64()*15, y + rng.Float64()*15}}
+			want := []int{}
+
+			for i, bx := range bxs {
+
+				if l, u := bx.Limits(); intersects(l, u, tb[0][:], tb[1][:]) {
+					want = append(want, i)
+				}
+
+			}
+
+			if got := sorted(tree.OverlapsTile(tb)); !equalInts(got, want) {
+				t.Fatalf("leaf %d, OverlapsTile(%v) = %v, want %v", lf, tb, got, want)
+			}
+
+		}
+
+	}
+
+}
+
+func TestOverlapsLeafBuckets(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(8))
+	bxs := randomBoxes(rng, 3000, 100, 10)
+
+	for _, lf := range []int{1, 4, 16, 5000} {
+
+		tree := NewBOXTree(bxs, WithLeafSize(lf))
+
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("leaf %d: %v", lf, err)
+		}
+
+		for q := 0; q < 300; q++ {
+
+			vals := []float64{rng.Float64() * 110, rng.Float64() * 110}
+
+			if got, want := sorted(tree.Overlaps(vals)), bruteOverlaps(bxs, vals); !equalInts(got, want) {
+				t.Fatalf("leaf %d, Overlaps(%v) = %v, want %v", lf, vals, got, want)
+			}
+
+		}
+
+	}
+
+}
+
+func BenchmarkNewBOXTree(b *testing.B) {
+
+	bxs := randomBoxes(rand.New(rand.NewSource(17)), 100000, 1000, 10)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		NewBOXTree(bxs)
+	}
+
+}
+
+func TestSnapshotSurvivesParentMutation(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(26))
+	bxs := randomBoxes(rng, 1000, 100, 10)
+	tree := NewBOXTree(bxs, WithColumnar(), WithPrecomputedAreas())
+
+	check := func(step string, snp *BOXTree) {
+
+		t.Helper()
+
+		for q := 0; q < 100; q++ {
+
+			vals := []float64{rng.Float64() * 110, rng.Float64() * 110}
+
+			if got, want := sorted(snp.Overlaps(vals)), bruteOverlaps(bxs, vals); !equalInts(got, want) {
+				t.Fatalf("after %s: snapshot Overlaps(%v) = %v, want %v", step, vals, got, want)
+			}
+
+		}
+
+		if err := snp.Validate(); err != nil {
+			t.Fatalf("after %s: %v", step, err)
+		}
+
+	}
+
+	snp := tree.Snapshot()
+	tree.Rebuild(randomBoxes(rng, 1000, 100, 10))
+	check("Rebuild", snp)
+
+	tree.Rebuild(bxs)
+	snp = tree.Snapshot()
+	tree.Reset()
+	tree.Rebuild(randomBoxes(rng, 1000, 100, 10))
+	check("Reset", snp)
+
+	tree = NewBOXTree(bxs)
+	snp = tree.Snapshot()
+	l, u := bxs[0].Limits()
+
+	if err := tree.UpdateLimits(0, l, []float64{u[0] + 50, u[1] + 50}); err != nil {
+		t.Fatal(err)
+	}
+
+	tree.Reaugment()
+	check("UpdateLimits", snp)
+
+	// the snapshot itself detaches on its own rebuild, leaving the parent intact
+	snp = tree.Snapshot()
+	snp.Rebuild(randomBoxes(rng, 1000, 100, 10))
+
+	if res := tree.Overlaps([]float64{u[0] + 40, u[1] + 40}); len(res) == 0 {
+		t.Fatal("parent lost its updated box after the snapshot rebuilt")
+	}
+
+}
+
+func TestSnapshotConcurrent(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(38))
+	bxs := randomBoxes(rng, 1000, 100, 10)
+	tree := NewBOXTree(bxs)
+	snps := make([]*BOXTree, 8)
+
+	var wg sync.WaitGroup
+
+	for g := range snps {
+
+		wg.Add(1)
+
+		go func(g int) {
+
+			defer wg.Done()
+
+			for i := 0; i < 100; i++ {
+
+				snps[g] = tree.Snapshot()
+				snps[g].Overlaps([]float64{50, 50})
+
+			}
+
+		}(g)
+
+	}
+
+	wg.Wait()
+
+	tree.Rebuild(randomBoxes(rng, 1000, 100, 10))
+
+	for g, snp := range snps {
+
+		// each snapshot detaches on its own rebuild, leaving the others intact
+		if g%2 == 1 {
+			snp.Rebuild(randomBoxes(rng, 500, 100, 10))
+			continue
+		}
+
+		for q := 0; q < 50; q++ {
+
+			vals := []float64{rng.Float64() * 110, rng.Float64() * 110}
+
+			if got, want := sorted(snp.Overlaps(vals)), bruteOverlaps(bxs, vals); !equalInts(got, want) {
+				t.Fatalf("snapshot %d: Overlaps(%v) = %v, want %v", g, vals, got, want)
+			}
+
+		}
+
+	}
+
+}
+
+func TestOverlapsPooledResultsDetached(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(51))
+	bxs := randomBoxes(rng, 2000, 100, 10)
+	tree := NewBOXTree(bxs)
+
+	qs := make([][]float64, 200)
+
+	for i := range qs {
+		qs[i] = []float64{rng.Float64() * 110, rng.Float64() * 110}
+	}
+
+	res := make([][][]int, 8)
+
+	var wg sync.WaitGroup
+
+	for g := range res {
+
+		wg.Add(1)
+
+		go func(g int) {
+
+			defer wg.Done()
+
+			// results are held while the pooled buffers are recycled by further queries on all goroutines
+			for _, vals := range qs {
+				res[g] = append(res[g], tree.Overlaps(vals))
+			}
+
+		}(g)
+
+	}
+
+	wg.Wait()
+
+	for g := range res {
+
+		for i, vals := range qs {
+
+			if got, want := sorted(res[g][i]), bruteOverlaps(bxs, vals); !equalInts(got, want) {
+				t.Fatalf("goroutine %d: held Overlaps(%v) = %v, want %v", g, vals, got, want)
+			}
+
+		}
+
+	}
+
+	if n := testing.AllocsPerRun(100, func() { tree.Overlaps(qs[0]) }); n > 1 && !raceEnabled {
+		t.Fatalf("Overlaps allocates %v times per call, want at most 1 for the result", n)
+	}
+
+}
+
+func BenchmarkOverlapsParallel(b *testing.B) {
+
+	rng := rand.New(rand.NewSource(3))
+	tree := NewBOXTree(randomBoxes(rng, 100000, 1000, 10))
+
+	qs := make([][]float64, 1024)
+
+	for i := range qs {
+		qs[i] = []float64{rng.Float64() * 1000, rng.Float64() * 1000}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+
+		for i := 0; pb.Next(); i++ {
+			tree.Overlaps(qs[i%len(qs)])
+		}
+
+	})
+
+}
+
+func TestOverlapsCustomPredicates(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(57))
+	bxs := make([]Box, 1000)
+
+	for i := range bxs {
+
+		x, y := float64(rng.Intn(100)), float64(rng.Intn(100))
+		bxs[i] = &testBox{[]float64{x, y}, []float64{x + float64(rng.Intn(10)), y + float64(rng.Intn(10))}}
+
+	}
+
+	strict := func(l, u, vals []float64) bool {
+		return l[0] < vals[0] && vals[0] < u[0] && l[1] < vals[1] && vals[1] < u[1]
+	}
+
+	halfOpen := func(l, u, vals []float64) bool {
+		return l[0] <= vals[0] && vals[0] < u[0] && l[1] <= vals[1] && vals[1] < u[1]
+	}
+
+	for _, lf := range []int{0, 8} {
+
+		tree := NewBOXTree(bxs, WithLeafSize(lf))
+
+		for q := 0; q < 300; q++ {
+
+			// integer values hit edges regularly, telling the predicates apart
+			vals := []float64{float64(rng.Intn(110)), float64(rng.Intn(110))}
+
+			for _, hit := range []func(l, u, vals []float64) bool{within, strict, halfOpen} {
+
+				want := []int{}
+
+				for i, bx := range bxs {
+
+					if l, u := bx.Limits(); hit(l, u, vals) {
+						want = append(want, i)
+					}
+
+				}
+
+				if got := sorted(tree.OverlapsCustom(vals, hit)); !equalInts(got, want) {
+					t.Fatalf("leaf %d, OverlapsCustom(%v) = %v, want %v", lf, vals, got, want)
+				}
+
+			}
+
+			// a predicate accepting everything only sees the boxes left by pruning, which include all inclusive overlaps, but not every box
+			all := func(l, u, vals []float64) bool { return true }
+			got, seen := tree.OverlapsCustom(vals, all), map[int]bool{}
+
+			for _, idx := range got {
+				seen[idx] = true
+			}
+
+			for _, idx := range bruteOverlaps(bxs, vals) {
+
+				if !seen[idx] {
+					t.Fatalf("leaf %d, permissive OverlapsCustom(%v) misses overlapping box %d", lf, vals, idx)
+				}
+
+			}
+
+			if len(got) == len(bxs) {
+				t.Fatalf("leaf %d, permissive OverlapsCustom(%v) did not prune", lf, vals)
+			}
+
+		}
+
+	}
+
+}
@@ -0,0 +1,134 @@
+// MIT License
+//
+// Copyright (c) 2022 geozelot (André Siefken)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package boxtree
+
+// builderChunk is the minimum number of elements a Builder allocates per arena chunk.
+const builderChunk = 1 << 12
+
+// Builder is the arena initialization object for many small trees;
+// builds trees whose index, limit reference and augmented limit Slices are carved from shared arena chunks.
+//
+// Thousands of tiny trees thus cost little more than two small allocations each (for the tree handle and its snapshot flag), and the whole set is freed together once no tree is referenced.
+// Trees stay valid until Reset, which reuses the arena and thereby invalidates all trees built before. Options allocating
+// further storage (e.g. WithColumnar or WithPrecomputedAreas) do so outside the arena. Not safe for concurrent use.
+type Builder struct {
+	ints []int
+	refs [][]float64
+	flts []float64
+	fn   func(n int) []float64
+	io   int
+	ro   int
+	fo   int
+}
+
+// NewBuilder is the Builder initialization function; the arena is allocated lazily by the first Build.
+func NewBuilder() *Builder {
+
+	bd := Builder{}
+	bd.fn = bd.floats
+
+	return &bd
+
+}
+
+// Build is the arena variant of NewBOXTree; creates the tree from the given Slice of Box, taking its storage from the arena.
+//
+// The tree's Slices are capped at their length, so a later Rebuild with more boxes allocates outside the arena.
+func (bd *Builder) Build(bxs []Box, opts ...Option) *BOXTree {
+
+	boT := BOXTree{}
+
+	for _, opt := range opts {
+		opt(&boT)
+	}
+
+	if boT.alloc == nil {
+		boT.alloc = bd.fn
+	}
+
+	boT.idxs = bd.indices(len(bxs))
+	boT.lmts = bd.limits(2 * len(bxs))
+
+	boT.buildTree(bxs)
+
+	return &boT
+
+}
+
+// Reset rewinds the arena to the start of its current chunks for reuse; all trees built so far become invalid.
+func (bd *Builder) Reset() {
+
+	bd.io, bd.ro, bd.fo = 0, 0, 0
+
+}
+
+// indices, limits and floats are the internal arena allocation functions, carving n elements from the current chunk or a new one.
+func (bd *Builder) indices(n int) []int {
+
+	if bd.io+n > len(bd.ints) {
+		bd.ints, bd.io = make([]int, chunk(n)), 0
+	}
+
+	s := bd.ints[bd.io : bd.io+n : bd.io+n]
+	bd.io += n
+
+	return s
+
+}
+
+func (bd *Builder) limits(n int) [][]float64 {
+
+	if bd.ro+n > len(bd.refs) {
+		bd.refs, bd.ro = make([][]float64, chunk(n)), 0
+	}
+
+	s := bd.refs[bd.ro : bd.ro+n : bd.ro+n]
+	bd.ro += n
+
+	return s
+
+}
+
+func (bd *Builder) floats(n int) []float64 {
+
+	if bd.fo+n > len(bd.flts) {
+		bd.flts, bd.fo = make([]float64, chunk(n)), 0
+	}
+
+	s := bd.flts[bd.fo : bd.fo+n : bd.fo+n]
+	bd.fo += n
+
+	return s
+
+}
+
+// chunk is an internal utility function, returning the arena chunk size for a request of n elements.
+func chunk(n int) int {
+
+	if n > builderChunk {
+		return n
+	}
+
+	return builderChunk
+
+}
@@ -0,0 +1,71 @@
+package boxtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestBuilderTinyTreesAllocs(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(54))
+	sets := make([][]Box, 1000)
+
+	for i := range sets {
+		sets[i] = randomBoxes(rng, 5, 100, 10)
+	}
+
+	bd := NewBuilder()
+	trees := make([]*BOXTree, len(sets))
+
+	build := func() {
+
+		bd.Reset()
+
+		for i, bxs := range sets {
+			trees[i] = bd.Build(bxs)
+		}
+
+	}
+
+	build()
+
+	for i, tree := range trees {
+
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("tree %d: %v", i, err)
+		}
+
+		for q := 0; q < 10; q++ {
+
+			vals := []float64{rng.Float64() * 110, rng.Float64() * 110}
+
+			if got, want := sorted(tree.Overlaps(vals)), bruteOverlaps(sets[i], vals); !equalInts(got, want) {
+				t.Fatalf("tree %d: Overlaps(%v) = %v, want %v", i, vals, got, want)
+			}
+
+		}
+
+	}
+
+	if raceEnabled {
+		return
+	}
+
+	// per tree only the handle and its copy-on-write flag, the arena chunks are reused after Reset
+	if n := testing.AllocsPerRun(10, build); n > 2*float64(len(sets))+16 {
+		t.Fatalf("building %d tiny trees allocates %v times, want at most %d", len(sets), n, 2*len(sets)+16)
+	}
+
+	n := testing.AllocsPerRun(10, func() {
+
+		for i, bxs := range sets {
+			trees[i] = NewBOXTree(bxs)
+		}
+
+	})
+
+	if n <= 4*float64(len(sets)) {
+		t.Fatalf("building %d tiny trees without a Builder allocates %v times, want more than %d", len(sets), n, 4*len(sets))
+	}
+
+}
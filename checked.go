@@ -0,0 +1,199 @@
+// MIT License
+//
+// Copyright (c) 2022 geozelot (André Siefken)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package boxtree
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// NewBOXTreeChecked is the guarded initialization function;
+// creates the tree from the given Slice of Box like NewBOXTree, but returns an error instead of building from invalid input:
+// ErrDimensionMismatch, ErrNonFinite or ErrInvalidBox for malformed boxes, ErrMaxDepth for a tree deeper than allowed by WithMaxDepth,
+// ErrUnsorted for input given under WithPresorted that is not in tree layout. NaN limits are accepted under WithNaNUnbounded.
+//
+// The checks happen before sorting, so rejected input costs no build time; the depth check also bounds the recursion depth of the build.
+func NewBOXTreeChecked(bxs []Box, opts ...Option) (*BOXTree, error) {
+
+	boT := BOXTree{}
+
+	for _, opt := range opts {
+		opt(&boT)
+	}
+
+	if boT.depth > 0 && bits.Len(uint(len(bxs))) > boT.depth {
+		return nil, ErrMaxDepth
+	}
+
+	for i, v := range bxs {
+
+		if err := boT.check(v.Limits()); err != nil {
+			return nil, fmt.Errorf("%w: box %d", err, i)
+		}
+
+	}
+
+	if boT.presorted {
+
+		if err := boT.checkPresorted(bxs); err != nil {
+			return nil, err
+		}
+
+	}
+
+	boT.buildTree(bxs)
+
+	return &boT, nil
+
+}
+
+// RebuildChecked is the guarded variant of Rebuild;
+// returns ErrDimensionMismatch if the tree holds weights or labels whose number differs from the number of given boxes,
+// and otherwise the errors of NewBOXTreeChecked. Rejected input leaves the tree unchanged.
+func (boT *BOXTree) RebuildChecked(bxs []Box) error {
+
+	if boT.wts != nil && len(boT.wts) != len(bxs) {
+		return fmt.Errorf("%w: %d weights for %d boxes", ErrDimensionMismatch, len(boT.wts), len(bxs))
+	}
+
+	if boT.lbls != nil && len(boT.lbls) != len(bxs) {
+		return fmt.Errorf("%w: %d labels for %d boxes", ErrDimensionMismatch, len(boT.lbls), len(bxs))
+	}
+
+	if boT.depth > 0 && bits.Len(uint(len(bxs))) > boT.depth {
+		return ErrMaxDepth
+	}
+
+	for i, v := range bxs {
+
+		if err := boT.check(v.Limits()); err != nil {
+			return fmt.Errorf("%w: box %d", err, i)
+		}
+
+	}
+
+	if boT.presorted {
+
+		if err := boT.checkPresorted(bxs); err != nil {
+			return err
+		}
+
+	}
+
+	boT.Rebuild(bxs)
+
+	return nil
+
+}
+
+// check is the Option aware variant of checkBox;
+// with WithNaNUnbounded, accepts NaN limits as unbounded, still rejecting ±Inf and misordered defined limits.
+func (boT *BOXTree) check(l, u []float64) error {
+
+	if !boT.unbounded || len(l) < 2 || len(l) != len(u) {
+		return checkBox(l, u)
+	}
+
+	for ax := 0; ax < 2; ax++ {
+
+		nl, nu := math.IsNaN(l[ax]), math.IsNaN(u[ax])
+
+		if !nl && !finite(l[ax]) || !nu && !finite(u[ax]) {
+			return ErrNonFinite
+		}
+
+		if !nl && !nu && l[ax] > u[ax] {
+			return ErrInvalidBox
+		}
+
+	}
+
+	return nil
+
+}
+
+// checkPresorted is an internal utility function, validating that the given Slice of Box is in the tree layout expected by WithPresorted;
+// augments a scratch copy of the limit references, leaving the tree untouched.
+func (boT *BOXTree) checkPresorted(bxs []Box) error {
+
+	idxs, lmts, ags := make([]int, len(bxs)), make([][]float64, 2*len(bxs)), make([]float64, 2*len(bxs))
+
+	for i, v := range bxs {
+
+		idxs[i] = i
+		lmts[2*i], lmts[2*i+1] = v.Limits()
+
+		if boT.unbounded {
+			lmts[2*i], lmts[2*i+1] = unbound(lmts[2*i], lmts[2*i+1])
+		}
+
+	}
+
+	augment(lmts, ags, idxs, 0, 1, boT.leaf)
+
+	if err := validate(lmts, ags, idxs, 0, 1, boT.leaf, 0); err != nil {
+		return fmt.Errorf("%w: %v", ErrUnsorted, err)
+	}
+
+	return nil
+
+}
+
+// OverlapsChecked is the guarded variant of Overlaps;
+// traverses the tree like OverlapsCustom with the standard predicate, but returns ErrDimensionMismatch or ErrNonFinite
+// for malformed values, and ErrMaxDepth as soon as the traversal stack grows beyond what a tree of the depth set via WithMaxDepth
+// can require (e.g. for a rebuilt or corrupted tree).
+func (boT *BOXTree) OverlapsChecked(vals []float64) ([]int, error) {
+
+	if err := checkVals(vals); err != nil {
+		return nil, err
+	}
+
+	buf := buffers.Get().(*buffer)
+	res := buf.res[:0]
+
+	if boT.depth > 0 {
+		buf.lim = 3 * (boT.depth + 1)
+	}
+
+	boT.walk(buf, vals, within, func(cn int) bool {
+
+		res = append(res, boT.idxs[cn])
+		return true
+
+	})
+
+	out, err := make([]int, len(res)), buf.err
+	copy(out, res)
+
+	buf.res, buf.lim, buf.err = res[:0], 0, nil
+	buffers.Put(buf)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+
+}
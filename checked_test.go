@@ -0,0 +1,50 @@
+package boxtree
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMaxDepthCraftedInput(t *testing.T) {
+
+	// 1000 identical boxes all containing the query point: a tree of 10 levels, every node of which the query visits
+	bxs := make([]Box, 1000)
+
+	for i := range bxs {
+		bxs[i] = &testBox{[]float64{0, 0}, []float64{1, 1}}
+	}
+
+	if _, err := NewBOXTreeChecked(bxs, WithMaxDepth(4)); !errors.Is(err, ErrMaxDepth) {
+		t.Fatalf("NewBOXTreeChecked of 1000 boxes at depth 4: err = %v, want ErrMaxDepth", err)
+	}
+
+	tree, err := NewBOXTreeChecked(bxs[:15], WithMaxDepth(4))
+
+	if err != nil {
+		t.Fatalf("NewBOXTreeChecked of 15 boxes at depth 4: %v", err)
+	}
+
+	if err := tree.RebuildChecked(bxs); !errors.Is(err, ErrMaxDepth) {
+		t.Fatalf("RebuildChecked of 1000 boxes at depth 4: err = %v, want ErrMaxDepth", err)
+	}
+
+	if res, err := tree.OverlapsChecked([]float64{0.5, 0.5}); err != nil || len(res) != 15 {
+		t.Fatalf("OverlapsChecked after rejected rebuild = %d results, %v; want 15, nil", len(res), err)
+	}
+
+	// an unchecked rebuild outgrows the depth limit, which the checked traversal then trips over
+	tree.Rebuild(bxs)
+
+	if _, err := tree.OverlapsChecked([]float64{0.5, 0.5}); !errors.Is(err, ErrMaxDepth) {
+		t.Fatalf("OverlapsChecked on a rebuilt deeper tree: err = %v, want ErrMaxDepth", err)
+	}
+
+	if res, err := tree.OverlapsChecked([]float64{5, 5}); err != nil || len(res) != 0 {
+		t.Fatalf("OverlapsChecked pruned at the root = %v, %v; want none, nil", res, err)
+	}
+
+	if res, err := NewBOXTree(bxs, WithMaxDepth(10)).OverlapsChecked([]float64{0.5, 0.5}); err != nil || len(res) != 1000 {
+		t.Fatalf("OverlapsChecked within the depth limit = %d results, %v; want 1000, nil", len(res), err)
+	}
+
+}
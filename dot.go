@@ -0,0 +1,95 @@
+// MIT License
+//
+// Copyright (c) 2022 geozelot (André Siefken)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package boxtree
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// WriteDOT is the Graphviz export function;
+// writes the implicit tree structure to w, one record node per tree node labeled with its original index, position,
+// split axis, limits and augmented limits, with edges to its left (L) and right (R) child nodes.
+//
+// Nodes deeper than depth levels are omitted (no cap for depth < 1). Returns the first write error.
+func (boT *BOXTree) WriteDOT(w io.Writer, depth int) error {
+
+	var err error
+
+	pf := func(format string, args ...interface{}) {
+
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+
+	}
+
+	pf("digraph boxtree {\n\tnode [shape=record];\n")
+
+	stk := []int{0, len(boT.idxs) - 1, boT.ax0, 1}
+
+	for len(stk) > 0 && err == nil {
+
+		lb, rb, ax, dp := stk[len(stk)-4], stk[len(stk)-3], stk[len(stk)-2], stk[len(stk)-1]
+		stk = stk[:len(stk)-4]
+
+		if lb > rb {
+			continue
+		}
+
+		cn := int(math.Ceil(float64(lb+rb) / 2.0))
+		l, u, ag := boT.lmts[2*cn], boT.lmts[2*cn+1], boT.ags[2*cn:2*cn+2]
+
+		if rb-lb < boT.leaf {
+
+			pf("\tn%d [label=\"{bucket|pos %d-%d, axis %d|max %g, min %g}\"];\n", cn, lb, rb, ax, ag[0], ag[1])
+			continue
+
+		}
+
+		pf("\tn%d [label=\"{#%d|pos %d, axis %d|lower %g %g|upper %g %g|max %g, min %g}\"];\n",
+			cn, boT.idxs[cn], cn, ax, l[0], l[1], u[0], u[1], ag[0], ag[1])
+
+		if depth > 0 && dp >= depth {
+			continue
+		}
+
+		for _, ch := range [][3]int{{lb, cn - 1, 'L'}, {cn + 1, rb, 'R'}} {
+
+			if ch[0] <= ch[1] {
+
+				pf("\tn%d -> n%d [label=\"%c\"];\n", cn, int(math.Ceil(float64(ch[0]+ch[1])/2.0)), ch[2])
+				stk = append(stk, ch[0], ch[1], (ax+boT.step())%2, dp+1)
+
+			}
+
+		}
+
+	}
+
+	pf("}\n")
+
+	return err
+
+}
@@ -0,0 +1,97 @@
+// MIT License
+//
+// Copyright (c) 2022 geozelot (André Siefken)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package boxtree
+
+import (
+	"errors"
+	"math"
+)
+
+// Error set of the package; returned (possibly wrapped with details) by error-returning functions, to be matched via errors.Is.
+var (
+	// ErrMaxDepth is returned by checked functions if the tree, or a traversal of it, exceeds the depth set via WithMaxDepth.
+	ErrMaxDepth = errors.New("boxtree: maximum depth exceeded")
+
+	// ErrDimensionMismatch is returned if box limits or query values have fewer than two values, or lower and upper limits differ in length.
+	ErrDimensionMismatch = errors.New("boxtree: dimension mismatch")
+
+	// ErrInvalidBox is returned if a lower limit of a box exceeds its upper limit on any axis.
+	ErrInvalidBox = errors.New("boxtree: lower limit exceeds upper limit")
+
+	// ErrEmptyTree is returned by functions that require at least one stored box.
+	ErrEmptyTree = errors.New("boxtree: empty tree")
+
+	// ErrNonFinite is returned if box limits or query values contain NaN or ±Inf.
+	ErrNonFinite = errors.New("boxtree: non-finite value")
+
+	// ErrUnsorted is returned by checked functions if input given under WithPresorted is not in tree layout.
+	ErrUnsorted = errors.New("boxtree: presorted input not in tree layout")
+
+	// ErrFormat is returned by OpenFile if the file is not a tree written by BuildToFile, or is truncated.
+	ErrFormat = errors.New("boxtree: invalid tree file")
+)
+
+// checkBox is an internal utility function, validating the limits of a single box.
+func checkBox(l, u []float64) error {
+
+	if len(l) < 2 || len(l) != len(u) {
+		return ErrDimensionMismatch
+	}
+
+	for ax := 0; ax < 2; ax++ {
+
+		if !finite(l[ax]) || !finite(u[ax]) {
+			return ErrNonFinite
+		}
+
+		if l[ax] > u[ax] {
+			return ErrInvalidBox
+		}
+
+	}
+
+	return nil
+
+}
+
+// checkVals is an internal utility function, validating query values.
+func checkVals(vals []float64) error {
+
+	if len(vals) < 2 {
+		return ErrDimensionMismatch
+	}
+
+	if !finite(vals[0]) || !finite(vals[1]) {
+		return ErrNonFinite
+	}
+
+	return nil
+
+}
+
+// finite is an internal utility function, checking that a value is neither NaN nor ±Inf.
+func finite(val float64) bool {
+
+	return !math.IsNaN(val) && !math.IsInf(val, 0)
+
+}
@@ -0,0 +1,73 @@
+package boxtree
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestErrorSet(t *testing.T) {
+
+	box := func(l0, l1, u0, u1 float64) []Box {
+		return []Box{&testBox{[]float64{l0, l1}, []float64{u0, u1}}}
+	}
+
+	for _, tc := range []struct {
+		name string
+		run  func() error
+		want error
+	}{
+		{"invalid box", func() error {
+			_, err := NewBOXTreeChecked(box(2, 0, 1, 1))
+			return err
+		}, ErrInvalidBox},
+		{"invalid update", func() error {
+			return NewBOXTree(box(0, 0, 1, 1)).UpdateLimits(0, []float64{0, 2}, []float64{1, 1})
+		}, ErrInvalidBox},
+		{"non-finite box", func() error {
+			_, err := NewBOXTreeChecked(box(0, math.Inf(-1), 1, 1))
+			return err
+		}, ErrNonFinite},
+		{"non-finite query", func() error {
+			_, err := NewBOXTree(box(0, 0, 1, 1)).OverlapsChecked([]float64{math.NaN(), 0})
+			return err
+		}, ErrNonFinite},
+		{"empty tree", func() error {
+			return NewBOXTree([]Box{}).UpdateLimits(0, []float64{0, 0}, []float64{1, 1})
+		}, ErrEmptyTree},
+		{"max depth", func() error {
+			_, err := NewBOXTreeChecked(append(box(0, 0, 1, 1), box(0, 0, 1, 1)...), WithMaxDepth(1))
+			return err
+		}, ErrMaxDepth},
+		{"dimension mismatch", func() error {
+			_, err := NewBOXTreeChecked([]Box{&testBox{[]float64{0}, []float64{1}}})
+			return err
+		}, ErrDimensionMismatch},
+		{"unsorted", func() error {
+			_, err := NewBOXTreeChecked(append(box(5, 5, 6, 6), append(box(0, 0, 1, 1), box(1, 1, 2, 2)...)...), WithPresorted())
+			return err
+		}, ErrUnsorted},
+		{"format", func() error {
+			_, err := NewDiskBOXTree(bytes.NewReader([]byte("not a tree file at all!!")))
+			return err
+		}, ErrFormat},
+	} {
+
+		err := tc.run()
+
+		if !errors.Is(err, tc.want) {
+			t.Fatalf("%s: err = %v, want %v", tc.name, err, tc.want)
+		}
+
+		for _, other := range []error{ErrInvalidBox, ErrNonFinite, ErrEmptyTree, ErrMaxDepth, ErrDimensionMismatch, ErrUnsorted, ErrFormat} {
+
+			if other != tc.want && errors.Is(err, other) {
+				t.Fatalf("%s: err = %v also matches %v", tc.name, err, other)
+			}
+
+		}
+
+	}
+
+}
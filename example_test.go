@@ -0,0 +1,151 @@
+package boxtree_test
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/geozelot/boxtree"
+)
+
+// SimpleBox is a simple Struct implicitly implementing the Box interface.
+type SimpleBox struct {
+	MinX, MinY, MaxX, MaxY float64
+}
+
+// Limits accesses the box limits.
+func (sb *SimpleBox) Limits() (Lower, Upper []float64) {
+	return []float64{sb.MinX, sb.MinY}, []float64{sb.MaxX, sb.MaxY}
+}
+
+func ExampleNewBOXTree() {
+
+	inputBoxes := []boxtree.Box{
+		&SimpleBox{MinX: 4.0, MinY: 6.0, MaxX: 8.0, MaxY: 10.0},
+		&SimpleBox{MinX: 5.0, MinY: 5.0, MaxX: 11.0, MaxY: 9.0},
+		&SimpleBox{MinX: 1.0, MinY: 4.0, MaxX: 4.0, MaxY: 7.0},
+		&SimpleBox{MinX: 2.0, MinY: 3.0, MaxX: 3.0, MaxY: 4.0},
+		&SimpleBox{MinX: 4.0, MinY: 6.0, MaxX: 8.0, MaxY: 10.0},
+		&SimpleBox{MinX: 6.0, MinY: 3.0, MaxX: 8.0, MaxY: 8.0},
+		&SimpleBox{MinX: 2.0, MinY: 6.0, MaxX: 7.0, MaxY: 7.0},
+	}
+
+	tree := boxtree.NewBOXTree(inputBoxes)
+
+	fmt.Println(tree.Len(), "boxes")
+
+	// Output:
+	// 7 boxes
+
+}
+
+func ExampleBOXTree_Overlaps() {
+
+	inputBoxes := []boxtree.Box{
+		&SimpleBox{MinX: 4.0, MinY: 6.0, MaxX: 8.0, MaxY: 10.0},
+		&SimpleBox{MinX: 5.0, MinY: 5.0, MaxX: 11.0, MaxY: 9.0},
+		&SimpleBox{MinX: 1.0, MinY: 4.0, MaxX: 4.0, MaxY: 7.0},
+		&SimpleBox{MinX: 2.0, MinY: 3.0, MaxX: 3.0, MaxY: 4.0},
+		&SimpleBox{MinX: 4.0, MinY: 6.0, MaxX: 8.0, MaxY: 10.0},
+		&SimpleBox{MinX: 6.0, MinY: 3.0, MaxX: 8.0, MaxY: 8.0},
+		&SimpleBox{MinX: 2.0, MinY: 6.0, MaxX: 7.0, MaxY: 7.0},
+	}
+
+	tree := boxtree.NewBOXTree(inputBoxes)
+
+	// the returned indices reference inputBoxes; their order is unspecified
+	matches := tree.Overlaps([]float64{3.2, 6.3})
+	sort.Ints(matches)
+
+	for _, idx := range matches {
+
+		lower, upper := inputBoxes[idx].Limits()
+		fmt.Printf("index %d: %v %v\n", idx, lower, upper)
+
+	}
+
+	// a point outside every box yields an empty, non-nil Slice
+	fmt.Println(len(tree.Overlaps([]float64{20.0, 20.0})), "matches")
+
+	// Output:
+	// index 2: [1 4] [4 7]
+	// index 6: [2 6] [7 7]
+	// 0 matches
+
+}
+
+func ExampleRectBox() {
+
+	tree := boxtree.NewBOXTree([]boxtree.Box{
+		boxtree.NewRect(1.0, 4.0, 4.0, 7.0),
+		boxtree.NewRect(2.0, 3.0, 3.0, 4.0),
+		&boxtree.RectBox{0.0, 0.0, 10.0, 10.0},
+	})
+
+	matches := tree.Overlaps([]float64{2.5, 3.5})
+	sort.Ints(matches)
+
+	fmt.Println(matches)
+
+	// Output:
+	// [1 2]
+
+}
+
+func ExampleOverlaps() {
+
+	inputBoxes := []boxtree.Box{
+		&SimpleBox{MinX: 4.0, MinY: 6.0, MaxX: 8.0, MaxY: 10.0},
+		&SimpleBox{MinX: 1.0, MinY: 4.0, MaxX: 4.0, MaxY: 7.0},
+		&SimpleBox{MinX: 2.0, MinY: 6.0, MaxX: 7.0, MaxY: 7.0},
+	}
+
+	// a single query without keeping a tree; indices are ascending
+	fmt.Println(boxtree.Overlaps(inputBoxes, []float64{3.2, 6.3}))
+
+	// Output:
+	// [1 2]
+
+}
+
+func ExampleBOXTree_Root() {
+
+	inputBoxes := []boxtree.Box{
+		&SimpleBox{MinX: 4.0, MinY: 6.0, MaxX: 8.0, MaxY: 10.0},
+		&SimpleBox{MinX: 5.0, MinY: 5.0, MaxX: 11.0, MaxY: 9.0},
+		&SimpleBox{MinX: 1.0, MinY: 4.0, MaxX: 4.0, MaxY: 7.0},
+		&SimpleBox{MinX: 2.0, MinY: 3.0, MaxX: 3.0, MaxY: 4.0},
+		&SimpleBox{MinX: 6.0, MinY: 3.0, MaxX: 8.0, MaxY: 8.0},
+		&SimpleBox{MinX: 2.0, MinY: 6.0, MaxX: 7.0, MaxY: 7.0},
+	}
+
+	tree := boxtree.NewBOXTree(inputBoxes)
+
+	var sum func(nd *boxtree.Node) float64
+
+	// sums the areas of the boxes stored at nodes without children
+	sum = func(nd *boxtree.Node) float64 {
+
+		if nd == nil {
+			return 0
+		}
+
+		if l, r := nd.Left(), nd.Right(); l != nil || r != nil {
+			return sum(l) + sum(r)
+		}
+
+		lower, upper := nd.Limits()
+		fmt.Printf("leaf %d: %v %v\n", nd.Index(), lower, upper)
+
+		return (upper[0] - lower[0]) * (upper[1] - lower[1])
+
+	}
+
+	fmt.Println("total leaf area", sum(tree.Root()))
+
+	// Output:
+	// leaf 3: [2 3] [3 4]
+	// leaf 5: [2 6] [7 7]
+	// leaf 4: [6 3] [8 8]
+	// total leaf area 16
+
+}
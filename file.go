@@ -0,0 +1,655 @@
+// MIT License
+//
+// Copyright (c) 2022 geozelot (André Siefken)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package boxtree
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	gosort "sort"
+)
+
+// fileMagic and fileVersion head every tree file written by BuildToFile, fileHeader and fileNode are the sizes of its header and node records;
+// fileChunk is the number of boxes whose limits are allocated at once while reading, fileBudget the default number of boxes held in memory by BuildToFile.
+const (
+	fileMagic   = "BOXT"
+	fileVersion = 1
+	fileHeader  = 24
+	fileNode    = 56
+	fileChunk   = 1 << 10
+	fileBudget  = 1 << 18
+)
+
+// BuildToFile is the file based builder for large static datasets;
+// reads boxes from r, builds the tree and writes its flat layout to the file at path, replacing any existing file.
+//
+// Input is a stream of boxes as four little-endian float64 values each, in order lower x, lower y, upper x, upper y;
+// boxes are indexed by their position in the stream. The file holds a 24 byte header followed by 56 bytes per box
+// (index as int64, lower and upper limits and augmented limits as float64), in node order; open it via OpenFile or OpenDisk.
+//
+// It is BuildToFileBudget with a budget of 2^18 boxes.
+func BuildToFile(r io.Reader, path string) error {
+
+	return BuildToFileBudget(r, path, fileBudget)
+
+}
+
+// BuildToFileBudget is the out-of-core variant of BuildToFile, holding at most budget boxes in memory at once (the default for budget < 1).
+//
+// Boxes are streamed into the output file first, then laid out in place: each range of more than budget boxes is sorted externally
+// on its split axis, in sorted runs of budget boxes merged back into the range, and smaller ranges are sorted and augmented in memory.
+// Memory use is about 160 bytes per box of budget. Disk usage is the output file plus one temporary run file at a time,
+// created next to it and removed before returning, of 56 bytes per box of the range being sorted; peak usage is thus about twice the output file.
+func BuildToFileBudget(r io.Reader, path string, budget int) error {
+
+	if budget < 1 {
+		budget = fileBudget
+	}
+
+	f, err := os.Create(path)
+
+	if err != nil {
+		return err
+	}
+
+	ext := external{f: f, dir: filepath.Dir(path), budget: budget, st: 1}
+	n, err := ext.spool(r)
+
+	if err == nil {
+		err = ext.place(0, n-1, ext.ax0)
+	}
+
+	if err == nil {
+
+		var hdr [fileHeader]byte
+
+		copy(hdr[:4], fileMagic)
+		binary.LittleEndian.PutUint32(hdr[4:], fileVersion)
+		binary.LittleEndian.PutUint64(hdr[8:], uint64(n))
+		binary.LittleEndian.PutUint32(hdr[16:], uint32(ext.ax0))
+
+		if ext.st == 0 {
+			binary.LittleEndian.PutUint32(hdr[20:], 1)
+		}
+
+		_, err = f.WriteAt(hdr[:], 0)
+
+	}
+
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+
+}
+
+// external is the internal state of BuildToFileBudget;
+// holds the output file, the directory for run files, the memory budget and the leveling of the tree.
+type external struct {
+	f      *os.File
+	dir    string
+	budget int
+	ax0    int
+	st     int
+}
+
+// spool is the input phase of BuildToFileBudget;
+// streams the boxes from r into unsorted node records after the header, detecting degenerate axes as in BOXTree.flatten; returns the number of boxes.
+func (ext *external) spool(r io.Reader) (int, error) {
+
+	br, bw := bufio.NewReader(r), bufio.NewWriter(ext.f)
+
+	var rec [32]byte
+	var nd [fileNode]byte
+	var v, v0 [6]float64
+
+	eq := [2]bool{true, true}
+
+	if _, err := bw.Write(make([]byte, fileHeader)); err != nil {
+		return 0, err
+	}
+
+	n := 0
+
+	for ; ; n++ {
+
+		if _, err := io.ReadFull(br, rec[:]); err == io.EOF {
+			break
+		} else if err != nil {
+			return 0, fmt.Errorf("boxtree: reading box %d: %w", n, err)
+		}
+
+		for j := 0; j < 4; j++ {
+			v[j] = math.Float64frombits(binary.LittleEndian.Uint64(rec[8*j:]))
+		}
+
+		if err := checkBox(v[0:2], v[2:4]); err != nil {
+			return 0, fmt.Errorf("%w: box %d", err, n)
+		}
+
+		if n == 0 {
+			v0 = v
+		}
+
+		for f := range eq {
+			eq[f] = eq[f] && v[f] == v0[f] && v[2+f] == v0[2+f]
+		}
+
+		putNode(nd[:], n, v)
+
+		if _, err := bw.Write(nd[:]); err != nil {
+			return 0, err
+		}
+
+	}
+
+	for f := 1; f >= 0 && n > 1; f-- {
+
+		if eq[f] {
+			ext.ax0, ext.st = (f+1)%2, 0
+			break
+		}
+
+	}
+
+	return n, bw.Flush()
+
+}
+
+// place is the layout phase of BuildToFileBudget;
+// orders and augments the node records of the range [lb, rb], split on axis ax, in memory if it fits the budget, otherwise by external sorting.
+func (ext *external) place(lb, rb, ax int) error {
+
+	if lb > rb {
+		return nil
+	}
+
+	if rb-lb < ext.budget {
+		return ext.inMemory(lb, rb, ax)
+	}
+
+	cn := int(math.Ceil(float64(lb+rb) / 2.0))
+
+	if err := ext.sortRange(lb, rb, cn, ax); err != nil {
+		return err
+	}
+
+	if err := ext.place(lb, cn-1, (ax+ext.st)%2); err != nil {
+		return err
+	}
+
+	return ext.place(cn+1, rb, (ax+ext.st)%2)
+
+}
+
+// inMemory loads the node records of [lb, rb], orders and augments them as a subtree split on axis ax and writes them back.
+func (ext *external) inMemory(lb, rb, ax int) error {
+
+	m := rb - lb + 1
+	buf := make([]byte, fileNode*m)
+
+	if _, err := ext.f.ReadAt(buf, int64(fileHeader+fileNode*lb)); err != nil {
+		return err
+	}
+
+	idxs, lmts, ags, blk := make([]int, m), make([][]float64, 2*m), make([]float64, 2*m), make([]float64, 4*m)
+
+	for i := range idxs {
+
+		idx, v := getNode(buf[fileNode*i:])
+		copy(blk[4*i:4*i+4], v[:4])
+
+		idxs[i] = idx
+		lmts[2*i], lmts[2*i+1] = blk[4*i:4*i+2:4*i+2], blk[4*i+2:4*i+4:4*i+4]
+
+	}
+
+	sort(lmts, idxs, ax, ext.st, 0)
+	augment(lmts, ags, idxs, ax, ext.st, 0)
+
+	for i, idx := range idxs {
+		putNode(buf[fileNode*i:], idx, [6]float64{lmts[2*i][0], lmts[2*i][1], lmts[2*i+1][0], lmts[2*i+1][1], ags[2*i], ags[2*i+1]})
+	}
+
+	_, err := ext.f.WriteAt(buf, int64(fileHeader+fileNode*lb))
+
+	return err
+
+}
+
+// sortRange is the external sorting function;
+// writes the node records of [lb, rb] as runs of budget records, each sorted by lower limit on axis ax, to a temporary file,
+// merges them back into the range and stores the augmented limits of the range on its midpoint node cn.
+func (ext *external) sortRange(lb, rb, cn, ax int) error {
+
+	tmp, err := ioutil.TempFile(ext.dir, ".boxtree-run-*")
+
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	max, min := math.Inf(-1), math.Inf(1)
+	buf := make([]byte, fileNode*ext.budget)
+	rns := runs{ax: ax}
+
+	for off := lb; off <= rb; off += ext.budget {
+
+		run := buf[:fileNode*minInt(ext.budget, rb-off+1)]
+
+		if _, err := ext.f.ReadAt(run, int64(fileHeader+fileNode*off)); err != nil {
+			return err
+		}
+
+		gosort.Sort(nodes{run, ax})
+
+		for i := 0; i < len(run); i += fileNode {
+
+			_, v := getNode(run[i:])
+			max, min = math.Max(max, v[2+ax]), math.Min(min, v[ax])
+
+		}
+
+		if _, err := tmp.WriteAt(run, int64(fileNode*(off-lb))); err != nil {
+			return err
+		}
+
+		rd := bufio.NewReader(io.NewSectionReader(tmp, int64(fileNode*(off-lb)), int64(len(run))))
+		rns.rds = append(rns.rds, rd)
+
+	}
+
+	for i := range rns.rds {
+
+		if hd, ok, err := rns.load(i); err != nil {
+			return err
+		} else if ok {
+			rns.hds = append(rns.hds, hd)
+		}
+
+	}
+
+	heap.Init(&rns)
+
+	bw := bufio.NewWriter(&offsetWriter{ext.f, int64(fileHeader + fileNode*lb)})
+
+	for pos := lb; rns.Len() > 0; pos++ {
+
+		hd := rns.hds[0]
+
+		if pos == cn {
+
+			idx, v := getNode(hd.nd[:])
+			v[4], v[5] = max, min
+			putNode(hd.nd[:], idx, v)
+
+		}
+
+		if _, err := bw.Write(hd.nd[:]); err != nil {
+			return err
+		}
+
+		if hd, ok, err := rns.load(hd.run); err != nil {
+			return err
+		} else if ok {
+			rns.hds[0] = hd
+			heap.Fix(&rns, 0)
+		} else {
+			heap.Pop(&rns)
+		}
+
+	}
+
+	return bw.Flush()
+
+}
+
+// minInt is an internal utility function, returning the smaller of two integers.
+func minInt(a, b int) int {
+
+	if a < b {
+		return a
+	}
+
+	return b
+
+}
+
+// head is the current node record of a sorted run, together with its lower limit on the merge axis.
+type head struct {
+	nd  [fileNode]byte
+	key float64
+	run int
+}
+
+// runs is the internal merge heap over sorted runs; holds the run readers, ordered by the key of their current head.
+type runs struct {
+	rds []*bufio.Reader
+	hds []head
+	ax  int
+}
+
+// load reads the next node record of run i; reports false once the run is exhausted.
+func (rns *runs) load(i int) (head, bool, error) {
+
+	hd := head{run: i}
+
+	if _, err := io.ReadFull(rns.rds[i], hd.nd[:]); err == io.EOF {
+		return hd, false, nil
+	} else if err != nil {
+		return hd, false, err
+	}
+
+	_, v := getNode(hd.nd[:])
+	hd.key = v[rns.ax]
+
+	return hd, true, nil
+
+}
+
+func (rns *runs) Len() int {
+	return len(rns.hds)
+}
+
+func (rns *runs) Less(i, j int) bool {
+	return rns.hds[i].key < rns.hds[j].key
+}
+
+func (rns *runs) Swap(i, j int) {
+	rns.hds[i], rns.hds[j] = rns.hds[j], rns.hds[i]
+}
+
+func (rns *runs) Push(x interface{}) {
+	rns.hds = append(rns.hds, x.(head))
+}
+
+func (rns *runs) Pop() interface{} {
+
+	hd := rns.hds[len(rns.hds)-1]
+	rns.hds = rns.hds[:len(rns.hds)-1]
+
+	return hd
+
+}
+
+// nodes is the internal sort.Interface over packed node records, ordering by lower limit on a single axis.
+type nodes struct {
+	buf []byte
+	ax  int
+}
+
+func (ns nodes) Len() int {
+	return len(ns.buf) / fileNode
+}
+
+func (ns nodes) Less(i, j int) bool {
+
+	_, a := getNode(ns.buf[fileNode*i:])
+	_, b := getNode(ns.buf[fileNode*j:])
+
+	return a[ns.ax] < b[ns.ax]
+
+}
+
+func (ns nodes) Swap(i, j int) {
+
+	var t [fileNode]byte
+
+	copy(t[:], ns.buf[fileNode*i:fileNode*(i+1)])
+	copy(ns.buf[fileNode*i:fileNode*(i+1)], ns.buf[fileNode*j:fileNode*(j+1)])
+	copy(ns.buf[fileNode*j:fileNode*(j+1)], t[:])
+
+}
+
+// offsetWriter is an internal io.Writer, writing sequentially to a file from the given offset on.
+type offsetWriter struct {
+	f   *os.File
+	off int64
+}
+
+func (ow *offsetWriter) Write(p []byte) (int, error) {
+
+	n, err := ow.f.WriteAt(p, ow.off)
+	ow.off += int64(n)
+
+	return n, err
+
+}
+
+// putNode and getNode are the internal node record codecs;
+// a record holds the original index followed by lower x, lower y, upper x, upper y and the maximum upper and minimum lower limit on the split axis.
+func putNode(b []byte, idx int, v [6]float64) {
+
+	binary.LittleEndian.PutUint64(b, uint64(idx))
+
+	for j, f := range v {
+		binary.LittleEndian.PutUint64(b[8+8*j:], math.Float64bits(f))
+	}
+
+}
+
+func getNode(b []byte) (idx int, v [6]float64) {
+
+	for j := range v {
+		v[j] = math.Float64frombits(binary.LittleEndian.Uint64(b[8+8*j:]))
+	}
+
+	return int(binary.LittleEndian.Uint64(b)), v
+
+}
+
+// OpenFile is the loader for files written by BuildToFile;
+// reads the flat layout back into a tree, without sorting or augmenting again.
+//
+// The whole layout is read into memory; to query a file without loading it, use OpenDisk. Check Validate() on files from untrusted sources.
+func OpenFile(path string) (*BOXTree, error) {
+
+	f, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+
+	var hdr [fileHeader]byte
+
+	if _, err := io.ReadFull(br, hdr[:]); err != nil || string(hdr[:4]) != fileMagic || binary.LittleEndian.Uint32(hdr[4:]) != fileVersion {
+		return nil, ErrFormat
+	}
+
+	n := binary.LittleEndian.Uint64(hdr[8:])
+	boT := BOXTree{idxs: []int{}, lmts: [][]float64{}, ags: []float64{}, cow: new(uint32), ax0: int(binary.LittleEndian.Uint32(hdr[16:]) % 2), flat: hdr[20] == 1}
+
+	var nd [fileNode]byte
+	var blk []float64
+
+	for i := uint64(0); i < n; i++ {
+
+		if _, err := io.ReadFull(br, nd[:]); err != nil {
+
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil, ErrFormat
+			}
+
+			return nil, err
+
+		}
+
+		if len(blk) == 0 {
+			blk = make([]float64, 4*fileChunk)
+		}
+
+		idx, v := getNode(nd[:])
+		l := blk[:4:4]
+		blk = blk[4:]
+
+		copy(l, v[:4])
+
+		boT.idxs = append(boT.idxs, idx)
+		boT.lmts = append(boT.lmts, l[0:2:2], l[2:4:4])
+		boT.ags = append(boT.ags, v[4], v[5])
+
+	}
+
+	boT.size = len(boT.idxs)
+
+	if !boT.IsConsistent() {
+		return nil, ErrFormat
+	}
+
+	return &boT, nil
+
+}
+
+// DiskBOXTree is the disk-backed package object;
+// queries a tree file written by BuildToFile through an io.ReaderAt, reading the nodes on each traversal path instead of holding them in memory.
+type DiskBOXTree struct {
+	ra   io.ReaderAt
+	cls  io.Closer
+	n    int
+	ax0  int
+	step int
+}
+
+// NewDiskBOXTree is the io.ReaderAt based initialization function for tree files written by BuildToFile;
+// reads the header only, returning ErrFormat for anything else. ra must stay readable while the tree is queried.
+func NewDiskBOXTree(ra io.ReaderAt) (*DiskBOXTree, error) {
+
+	var hdr [fileHeader]byte
+
+	if _, err := ra.ReadAt(hdr[:], 0); err != nil || string(hdr[:4]) != fileMagic || binary.LittleEndian.Uint32(hdr[4:]) != fileVersion {
+		return nil, ErrFormat
+	}
+
+	boT := DiskBOXTree{ra: ra, n: int(binary.LittleEndian.Uint64(hdr[8:])), ax0: int(binary.LittleEndian.Uint32(hdr[16:]) % 2), step: 1}
+
+	if hdr[20] == 1 {
+		boT.step = 0
+	}
+
+	return &boT, nil
+
+}
+
+// OpenDisk is the file based variant of NewDiskBOXTree; opens the file at path, to be released via Close.
+//
+// Nodes are read through the operating system's page cache, so repeated queries on hot paths cost no disk access.
+func OpenDisk(path string) (*DiskBOXTree, error) {
+
+	f, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	boT, err := NewDiskBOXTree(f)
+
+	if err != nil {
+
+		f.Close()
+
+		return nil, err
+
+	}
+
+	boT.cls = f
+
+	return boT, nil
+
+}
+
+// Close releases the file opened by OpenDisk; a no-op for trees created via NewDiskBOXTree.
+func (boT *DiskBOXTree) Close() error {
+
+	if boT.cls == nil {
+		return nil
+	}
+
+	return boT.cls.Close()
+
+}
+
+// Len is the size accessor; returns the number of boxes stored in the tree file.
+func (boT *DiskBOXTree) Len() int {
+
+	return boT.n
+
+}
+
+// Overlaps is the main entry point for box searches on disk;
+// traverses the tree file and collects boxes that overlap with the given values, returning ErrFormat for truncated files or any read error.
+func (boT *DiskBOXTree) Overlaps(vals []float64) ([]int, error) {
+
+	res := []int{}
+
+	var nd [fileNode]byte
+	var err error
+
+	descend(nil, boT.n, boT.ax0, boT.step, 2, func(lb, rb, cn, ax int) (left, right, ok bool) {
+
+		if _, err = boT.ra.ReadAt(nd[:], int64(fileHeader+fileNode*cn)); err != nil {
+
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				err = ErrFormat
+			}
+
+			return false, false, false
+
+		}
+
+		idx, v := getNode(nd[:])
+
+		if vals[ax] < v[5] {
+			return false, false, true
+		}
+
+		left, right = vals[ax] <= v[4], v[ax] <= vals[ax]
+
+		if right && within(v[0:2], v[2:4], vals) {
+			res = append(res, idx)
+		}
+
+		return left, right, true
+
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+
+}
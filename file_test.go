@@ -0,0 +1,249 @@
+package boxtree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// encodeBoxes packs boxes into the input stream format of BuildToFile.
+func encodeBoxes(bxs []Box) *bytes.Buffer {
+
+	buf := &bytes.Buffer{}
+
+	for _, bx := range bxs {
+
+		l, u := bx.Limits()
+
+		for _, v := range []float64{l[0], l[1], u[0], u[1]} {
+			binary.Write(buf, binary.LittleEndian, math.Float64bits(v))
+		}
+
+	}
+
+	return buf
+
+}
+
+func TestBuildToFileMatchesBruteForce(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "boxtree")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	rng := rand.New(rand.NewSource(27))
+	flat := randomBoxes(rng, 700, 100, 10)
+
+	for _, bx := range flat {
+
+		l, u := bx.Limits()
+		l[1], u[1] = 3, 4
+
+	}
+
+	for _, tc := range []struct {
+		name   string
+		bxs    []Box
+		budget int
+	}{
+		{"empty", nil, 0},
+		{"memory", randomBoxes(rng, 3000, 100, 10), 0},
+		{"external", randomBoxes(rng, 3000, 100, 10), 50},
+		{"tiny", randomBoxes(rng, 500, 100, 10), 1},
+		{"flat", flat, 40},
+	} {
+
+		path := filepath.Join(dir, tc.name+".boxt")
+
+		if err := BuildToFileBudget(encodeBoxes(tc.bxs), path, tc.budget); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		tree, err := OpenFile(path)
+
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		disk, err := OpenDisk(path)
+
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		if disk.Len() != len(tc.bxs) {
+			t.Fatalf("%s: Len = %d, want %d", tc.name, disk.Len(), len(tc.bxs))
+		}
+
+		for q := 0; q < 200; q++ {
+
+			vals := []float64{rng.Float64() * 110, rng.Float64() * 110}
+
+			if q%2 == 0 {
+				vals[1] = 3.5
+			}
+
+			want := bruteOverlaps(tc.bxs, vals)
+
+			if got := sorted(tree.Overlaps(vals)); !equalInts(got, want) {
+				t.Fatalf("%s: OpenFile Overlaps(%v) = %v, want %v", tc.name, vals, got, want)
+			}
+
+			res, err := disk.Overlaps(vals)
+
+			if err != nil {
+				t.Fatalf("%s: %v", tc.name, err)
+			}
+
+			if got := sorted(res); !equalInts(got, want) {
+				t.Fatalf("%s: DiskBOXTree Overlaps(%v) = %v, want %v", tc.name, vals, got, want)
+			}
+
+		}
+
+		if err := disk.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+	}
+
+	if left, _ := filepath.Glob(filepath.Join(dir, ".boxtree-run-*")); len(left) > 0 {
+		t.Fatalf("run files left behind: %v", left)
+	}
+
+}
+
+func TestDiskBOXTreeTruncated(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "boxtree")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "tree.boxt")
+
+	if err := BuildToFile(encodeBoxes(randomBoxes(rand.New(rand.NewSource(28)), 100, 100, 10)), path); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	disk, err := NewDiskBOXTree(bytes.NewReader(data[:len(data)/2]))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := disk.Overlaps([]float64{200, 200}); err != ErrFormat {
+		t.Fatalf("truncated file: err = %v, want ErrFormat", err)
+	}
+
+	if _, err := NewDiskBOXTree(bytes.NewReader([]byte("not a tree file at all!!"))); err != ErrFormat {
+		t.Fatalf("bad header: err = %v, want ErrFormat", err)
+	}
+
+}
+
+func TestOpenFileCorrupted(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "boxtree")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "tree.boxt")
+
+	if err := BuildToFile(encodeBoxes(randomBoxes(rand.New(rand.NewSource(70)), 100, 100, 10)), path); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bad := filepath.Join(dir, "bad.boxt")
+
+	open := func(b []byte) error {
+
+		if err := ioutil.WriteFile(bad, b, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := OpenFile(bad)
+		return err
+
+	}
+
+	// truncated within the header, at a node boundary and within a node
+	for _, n := range []int{0, 10, fileHeader, fileHeader + 50*fileNode, len(data) - 1} {
+
+		if err := open(data[:n]); err != ErrFormat {
+			t.Fatalf("file truncated to %d bytes: err = %v, want ErrFormat", n, err)
+		}
+
+	}
+
+	// an index beyond the number of boxes passes the reader, but not the consistency check
+	cor := append([]byte{}, data...)
+	binary.LittleEndian.PutUint64(cor[fileHeader+10*fileNode:], 1000)
+
+	if err := open(cor); err != ErrFormat {
+		t.Fatalf("out of range index: err = %v, want ErrFormat", err)
+	}
+
+	if err := open(data); err != nil {
+		t.Fatalf("intact file: %v", err)
+	}
+
+	tree := NewBOXTree(randomBoxes(rand.New(rand.NewSource(70)), 100, 100, 10))
+
+	for _, tc := range []struct {
+		name   string
+		damage func(boT *BOXTree)
+	}{
+		{"short limits", func(boT *BOXTree) { boT.lmts = boT.lmts[:len(boT.lmts)-1] }},
+		{"short augmented limits", func(boT *BOXTree) { boT.ags = boT.ags[:len(boT.ags)-2] }},
+		{"one dimensional limit", func(boT *BOXTree) { boT.lmts[7] = boT.lmts[7][:1] }},
+		{"negative index", func(boT *BOXTree) { boT.idxs[3] = -1 }},
+		{"index out of range", func(boT *BOXTree) { boT.idxs[3] = boT.size }},
+	} {
+
+		boT := &BOXTree{idxs: append([]int{}, tree.idxs...), lmts: append([][]float64{}, tree.lmts...), ags: append([]float64{}, tree.ags...), size: tree.size}
+		tc.damage(boT)
+
+		if boT.IsConsistent() {
+			t.Fatalf("%s: IsConsistent = true", tc.name)
+		}
+
+	}
+
+	if !tree.IsConsistent() {
+		t.Fatal("intact tree: IsConsistent = false")
+	}
+
+}
@@ -0,0 +1,52 @@
+//go:build go1.18
+// +build go1.18
+
+package boxtree
+
+import (
+	"testing"
+)
+
+// FuzzOverlaps cross-checks Overlaps of BOXTree, NDBOXTree with two dimensions, IntBOXTree and FixedBOXTree against brute force,
+// on integral boxes decoded from four bytes each (lower x, lower y, width, height) so that all variants see exactly the same limits.
+func FuzzOverlaps(f *testing.F) {
+
+	f.Add([]byte{}, int8(0), int8(0), uint8(0))
+	f.Add([]byte{0, 0, 4, 4, 2, 2, 1, 1, 2, 2, 0, 0}, int8(2), int8(2), uint8(0))
+	f.Add([]byte{250, 10, 8, 3, 251, 10, 8, 3, 0, 10, 0, 3, 5, 5, 5, 5, 9, 1, 0, 0}, int8(3), int8(11), uint8(4))
+
+	f.Fuzz(func(t *testing.T, data []byte, qx, qy int8, lf uint8) {
+
+		n := len(data) / 4
+		bxs, ibs, flt := make([]Box, n), make([]IntBox, n), make([]float64, 0, 4*n)
+
+		for i := range bxs {
+
+			x, y := int64(int8(data[4*i])), int64(int8(data[4*i+1]))
+			w, h := int64(data[4*i+2]%16), int64(data[4*i+3]%16)
+
+			bxs[i] = NewRect(float64(x), float64(y), float64(x+w), float64(y+h))
+			ibs[i] = &testIntBox{[]int64{x, y}, []int64{x + w, y + h}}
+			flt = append(flt, float64(x), float64(y), float64(x+w), float64(y+h))
+
+		}
+
+		vals := []float64{float64(qx), float64(qy)}
+		want := bruteOverlaps(bxs, vals)
+
+		for name, got := range map[string][]int{
+			"BOXTree":      NewBOXTree(bxs, WithLeafSize(int(lf%10))).Overlaps(vals),
+			"NDBOXTree":    NewBOXTreeNDFlat(flt, n, 2).Overlaps(vals),
+			"IntBOXTree":   NewIntBOXTree(ibs, WithLeafSize(int(lf%10))).Overlaps([]int64{int64(qx), int64(qy)}),
+			"FixedBOXTree": NewBOXTreeFixed(bxs, 1).Overlaps(vals),
+		} {
+
+			if got = sorted(got); !equalInts(got, want) {
+				t.Fatalf("%s.Overlaps(%v) = %v, want %v", name, vals, got, want)
+			}
+
+		}
+
+	})
+
+}
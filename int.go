@@ -0,0 +1,370 @@
+// MIT License
+//
+// Copyright (c) 2022 geozelot (André Siefken)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package boxtree
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// IntBox is the integer interface expected by NewIntBOXTree(); requires Limits method to access box limits.
+type IntBox interface {
+	Limits() (Lower, Upper []int64)
+}
+
+// IntBOXTree is the integer package object;
+// holds Slice of reference indices and the respective integer box limits, with the augmented limits in a dense Slice like BOXTree.
+type IntBOXTree struct {
+	idxs []int
+	lmts [][]int64
+	ags  []int64
+	leaf int
+}
+
+// buildTree is the internal tree construction function;
+// creates, sorts and augments nodes into Slices.
+func (boT *IntBOXTree) buildTree(bxs []IntBox) {
+
+	boT.idxs = make([]int, len(bxs))
+	boT.lmts = make([][]int64, 2*len(bxs))
+	boT.ags = make([]int64, 2*len(bxs))
+
+	for i, v := range bxs {
+
+		boT.idxs[i] = i
+		boT.lmts[2*i], boT.lmts[2*i+1] = v.Limits()
+
+	}
+
+	sortInt(boT.lmts, boT.idxs, 0, boT.leaf)
+	augmentInt(boT.lmts, boT.ags, boT.idxs, 0, boT.leaf)
+
+}
+
+// Overlaps is the main entry point for integer box searches;
+// traverses the tree and collects boxes that overlap with the given values.
+//
+// Runs on the same traversal and pooled scratch space as BOXTree.Overlaps, comparing integers only.
+func (boT *IntBOXTree) Overlaps(vals []int64) []int {
+
+	buf := buffers.Get().(*buffer)
+	res := buf.res[:0]
+
+	descend(buf, len(boT.idxs), 0, 1, 2, func(lb, rb, cn, ax int) (left, right, ok bool) {
+
+		max, min := boT.ags[2*cn], boT.ags[2*cn+1]
+
+		if rb-lb < boT.leaf {
+
+			if vals[ax] < min || max < vals[ax] {
+				return false, false, true
+			}
+
+			for i := lb; i <= rb; i++ {
+
+				if withinInt(boT.lmts[2*i], boT.lmts[2*i+1], vals) {
+					res = append(res, boT.idxs[i])
+				}
+
+			}
+
+			return false, false, true
+
+		}
+
+		l := boT.lmts[2*cn]
+		left, right = vals[ax] <= max, l[ax] <= vals[ax]
+
+		if right && withinInt(l, boT.lmts[2*cn+1], vals) {
+			res = append(res, boT.idxs[cn])
+		}
+
+		return left, right, true
+
+	})
+
+	out := make([]int, len(res))
+	copy(out, res)
+
+	buf.res = res[:0]
+	buffers.Put(buf)
+
+	return out
+
+}
+
+// withinInt is the integer overlap predicate, checking whether the values lie inside the given limits (inclusive).
+func withinInt(l, u, vals []int64) bool {
+
+	return l[0] <= vals[0] && vals[0] <= u[0] && l[1] <= vals[1] && vals[1] <= u[1]
+
+}
+
+// Len is the size accessor; returns the number of boxes stored in the tree.
+func (boT *IntBOXTree) Len() int {
+
+	return len(boT.idxs)
+
+}
+
+// Validate is the structural self-check;
+// verifies that every node splits its range by lower limit and that all augmented limits bound their subtrees,
+// returning an error describing the first violation found.
+func (boT *IntBOXTree) Validate() error {
+
+	return validateInt(boT.lmts, boT.ags, boT.idxs, 0, boT.leaf, 0)
+
+}
+
+// NewIntBOXTree is the integer initialization function;
+// creates the tree from the given Slice of IntBox, configured by the given Options.
+//
+// Of the Options, only WithLeafSize applies; all others configure float64 specific behavior of BOXTree and are ignored.
+func NewIntBOXTree(bxs []IntBox, opts ...Option) *IntBOXTree {
+
+	cfg := BOXTree{}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	boT := IntBOXTree{leaf: cfg.leaf}
+	boT.buildTree(bxs)
+
+	return &boT
+
+}
+
+// augmentInt is an internal utility function, storing maximum upper and minimum lower value of all child nodes in the augmented limits of the current node;
+// ranges of up to lf nodes form a single leaf bucket, augmented on its midpoint node only.
+func augmentInt(lmts [][]int64, ags []int64, idxs []int, ax int, lf int) {
+
+	if len(idxs) < 1 {
+		return
+	}
+
+	max, min := boundsInt(lmts, len(idxs), ax)
+
+	r := len(idxs) >> 1
+
+	ags[2*r] = max
+	ags[2*r+1] = min
+
+	if len(idxs) <= lf {
+		return
+	}
+
+	augmentInt(lmts[:2*r], ags[:2*r], idxs[:r], (ax+1)%2, lf)
+	augmentInt(lmts[2*r+2:], ags[2*r+2:], idxs[r+1:], (ax+1)%2, lf)
+
+}
+
+// boundsInt is an internal utility function, finding the maximum upper and minimum lower value of the first n nodes on axis ax.
+func boundsInt(lmts [][]int64, n int, ax int) (max, min int64) {
+
+	max, min = math.MinInt64, math.MaxInt64
+
+	for idx := 0; idx < n; idx++ {
+
+		if lmts[2*idx+1][ax] > max {
+			max = lmts[2*idx+1][ax]
+		}
+
+		if lmts[2*idx][ax] < min {
+			min = lmts[2*idx][ax]
+		}
+
+	}
+
+	return max, min
+
+}
+
+// validateInt is an internal utility function, checking the ordering and augmentation of the current node and all child nodes.
+func validateInt(lmts [][]int64, ags []int64, idxs []int, ax int, lf int, off int) error {
+
+	if len(idxs) < 1 {
+		return nil
+	}
+
+	r := len(idxs) >> 1
+	l := lmts[2*r]
+
+	for idx := range idxs {
+
+		if len(idxs) > lf && (idx < r && lmts[2*idx][ax] > l[ax] || idx > r && lmts[2*idx][ax] < l[ax]) {
+			return fmt.Errorf("boxtree: node %d out of order with node %d on axis %d", off+idx, off+r, ax)
+		}
+
+		if lmts[2*idx+1][ax] > ags[2*r] || lmts[2*idx][ax] < ags[2*r+1] {
+			return fmt.Errorf("boxtree: node %d not bounded by augmented limits of node %d on axis %d", off+idx, off+r, ax)
+		}
+
+	}
+
+	if len(idxs) <= lf {
+		return nil
+	}
+
+	if err := validateInt(lmts[:2*r], ags[:2*r], idxs[:r], (ax+1)%2, lf, off); err != nil {
+		return err
+	}
+
+	return validateInt(lmts[2*r+2:], ags[2*r+2:], idxs[r+1:], (ax+1)%2, lf, off+r+1)
+
+}
+
+// sortInt is an internal utility function, ordering the tree by lowest limits using Random Pivot QuickSelect;
+// places the median of each range on its midpoint node, alternating the axis per level and leaving ranges of up to lf nodes unordered.
+func sortInt(lmts [][]int64, idxs []int, ax int, lf int) {
+
+	if len(idxs) < 2 || len(idxs) <= lf {
+		return
+	}
+
+	k := medianInt(lmts, idxs, ax)
+
+	sortInt(lmts[:2*k], idxs[:k], (ax+1)%2, lf)
+	sortInt(lmts[2*k+2:], idxs[k+1:], (ax+1)%2, lf)
+
+}
+
+// medianInt is an internal utility function, moving the box with the median lowest limit on ax to the midpoint of the range
+// and partitioning the others around it; returns the midpoint.
+func medianInt(lmts [][]int64, idxs []int, ax int) int {
+
+	k := len(idxs) >> 1
+	lb, rb := 0, len(idxs)-1
+
+	for lb < rb {
+
+		swapInt(lmts, idxs, lb+rand.Int()%(rb-lb+1), rb)
+
+		pv := lmts[2*rb][ax]
+		l, e := lb, lb
+
+		for i := lb; i < rb; i++ {
+
+			if lmts[2*i][ax] < pv {
+
+				swapInt(lmts, idxs, i, e)
+				swapInt(lmts, idxs, e, l)
+
+				l++
+				e++
+
+			} else if lmts[2*i][ax] == pv {
+
+				swapInt(lmts, idxs, i, e)
+				e++
+
+			}
+
+		}
+
+		swapInt(lmts, idxs, e, rb)
+
+		if k < l {
+			rb = l - 1
+		} else if k > e {
+			lb = e + 1
+		} else {
+			break
+		}
+
+	}
+
+	return k
+
+}
+
+// swapInt is an internal utility function, exchanging two nodes along with their reference indices.
+func swapInt(lmts [][]int64, idxs []int, i, j int) {
+
+	idxs[i], idxs[j] = idxs[j], idxs[i]
+	lmts[2*i], lmts[2*i+1], lmts[2*j], lmts[2*j+1] = lmts[2*j], lmts[2*j+1], lmts[2*i], lmts[2*i+1]
+
+}
+
+// FixedBOXTree is the fixed-point package object;
+// holds an IntBOXTree over box limits scaled and rounded to integers, presenting the float64 API of BOXTree.
+type FixedBOXTree struct {
+	tree  *IntBOXTree
+	scale float64
+}
+
+// fixedBox is the internal IntBox implementation of FixedBOXTree, holding scaled limits in order lower x, lower y, upper x, upper y.
+type fixedBox [4]int64
+
+// Limits accesses the scaled box limits; implements IntBox.
+func (fb *fixedBox) Limits() (Lower, Upper []int64) {
+
+	return fb[0:2:2], fb[2:4:4]
+
+}
+
+// NewBOXTreeFixed is the fixed-point initialization function;
+// creates the tree from the given Slice of Box, multiplying all limits by scale and rounding them to the nearest int64.
+//
+// Queries compare integers only, so results are identical across platforms for identical inputs.
+// The resolution is 1/scale: values closer than that may round together, a boundary value within half of it of a limit counts as on it,
+// and values beyond ±2^63/scale are out of range. Options apply as for NewIntBOXTree.
+func NewBOXTreeFixed(bxs []Box, scale float64, opts ...Option) *FixedBOXTree {
+
+	fbs := make([]fixedBox, len(bxs))
+	ibs := make([]IntBox, len(bxs))
+
+	for i, v := range bxs {
+
+		l, u := v.Limits()
+		fbs[i] = fixedBox{fixed(l[0], scale), fixed(l[1], scale), fixed(u[0], scale), fixed(u[1], scale)}
+		ibs[i] = &fbs[i]
+
+	}
+
+	return &FixedBOXTree{NewIntBOXTree(ibs, opts...), scale}
+
+}
+
+// Overlaps is the main entry point for fixed-point box searches;
+// scales and rounds the given values like the box limits, then collects boxes that overlap with them.
+func (boT *FixedBOXTree) Overlaps(vals []float64) []int {
+
+	return boT.tree.Overlaps([]int64{fixed(vals[0], boT.scale), fixed(vals[1], boT.scale)})
+
+}
+
+// Len is the size accessor; returns the number of boxes stored in the tree.
+func (boT *FixedBOXTree) Len() int {
+
+	return boT.tree.Len()
+
+}
+
+// fixed is an internal utility function, scaling a value and rounding it to the nearest int64 (halves away from zero).
+func fixed(v, scale float64) int64 {
+
+	return int64(math.Round(v * scale))
+
+}
@@ -0,0 +1,159 @@
+package boxtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// testIntBox is a minimal IntBox implementation for tests.
+type testIntBox struct {
+	lower, upper []int64
+}
+
+func (tb *testIntBox) Limits() (Lower, Upper []int64) {
+	return tb.lower, tb.upper
+}
+
+func TestIntOverlapsMatchesBruteForce(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(18))
+
+	for _, lf := range []int{0, 8} {
+
+		bxs := make([]IntBox, 2000)
+
+		for i := range bxs {
+
+			x, y := rng.Int63n(1000)-500, rng.Int63n(1000)-500
+			bxs[i] = &testIntBox{[]int64{x, y}, []int64{x + rng.Int63n(50), y + rng.Int63n(50)}}
+
+		}
+
+		tree := NewIntBOXTree(bxs, WithLeafSize(lf))
+
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("leaf %d: %v", lf, err)
+		}
+
+		for q := 0; q < 300; q++ {
+
+			vals := []int64{rng.Int63n(1100) - 550, rng.Int63n(1100) - 550}
+			want := []int{}
+
+			for i, bx := range bxs {
+
+				if l, u := bx.Limits(); withinInt(l, u, vals) {
+					want = append(want, i)
+				}
+
+			}
+
+			if got := sorted(tree.Overlaps(vals)); !equalInts(got, want) {
+				t.Fatalf("leaf %d, Overlaps(%v) = %v, want %v", lf, vals, got, want)
+			}
+
+		}
+
+	}
+
+}
+
+func TestFixedOverlapsMatchesBoxTree(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(19))
+	bxs := make([]Box, 1000)
+
+	for i := range bxs {
+
+		x, y := float64(rng.Intn(200)), float64(rng.Intn(200))
+		bxs[i] = NewRect(x/4, y/4, x/4+float64(rng.Intn(20))/4, y/4+float64(rng.Intn(20))/4)
+
+	}
+
+	tree, fixed := NewBOXTree(bxs), NewBOXTreeFixed(bxs, 4, WithLeafSize(4))
+
+	for q := 0; q < 300; q++ {
+
+		vals := []float64{float64(rng.Intn(240)) / 4, float64(rng.Intn(240)) / 4}
+
+		if got, want := sorted(fixed.Overlaps(vals)), sorted(tree.Overlaps(vals)); !equalInts(got, want) {
+			t.Fatalf("FixedBOXTree.Overlaps(%v) = %v, want %v", vals, got, want)
+		}
+
+	}
+
+	if fixed.Len() != len(bxs) || NewIntBOXTree(nil).Len() != 0 {
+		t.Fatalf("Len = %d, want %d", fixed.Len(), len(bxs))
+	}
+
+}
+
+func TestFixedBoundaryAgreement(t *testing.T) {
+
+	// two machines derive the same decimal grid, one by multiplying and one by dividing, ending up an ulp apart on some values
+	mul := func(k int) float64 { return float64(k) * 0.1 }
+	div := func(k int) float64 { return float64(k) / 10.0 }
+
+	rng := rand.New(rand.NewSource(79))
+	ks := make([][4]int, 500)
+
+	for i := range ks {
+
+		x, y := rng.Intn(100), rng.Intn(100)
+		ks[i] = [4]int{x, y, x + rng.Intn(10), y + rng.Intn(10)}
+
+	}
+
+	build := func(grid func(int) float64) []Box {
+
+		bxs := make([]Box, len(ks))
+
+		for i, k := range ks {
+			bxs[i] = NewRect(grid(k[0]), grid(k[1]), grid(k[2]), grid(k[3]))
+		}
+
+		return bxs
+
+	}
+
+	a, b := build(mul), build(div)
+	fa, fb := NewBOXTreeFixed(a, 10), NewBOXTreeFixed(b, 10)
+	ta, tb := NewBOXTree(a), NewBOXTree(b)
+	floatDiffers := false
+
+	for q := 0; q < 500; q++ {
+
+		// queries sit on the grid, and so on many box edges, computed the other machine's way
+		qx, qy := rng.Intn(110), rng.Intn(110)
+		va, vb := []float64{div(qx), div(qy)}, []float64{mul(qx), mul(qy)}
+
+		want := []int{}
+
+		for i, k := range ks {
+
+			if k[0] <= qx && qx <= k[2] && k[1] <= qy && qy <= k[3] {
+				want = append(want, i)
+			}
+
+		}
+
+		if got := sorted(fa.Overlaps(va)); !equalInts(got, want) {
+			t.Fatalf("machine A Overlaps(%v) = %v, want %v", va, got, want)
+		}
+
+		if got := sorted(fb.Overlaps(vb)); !equalInts(got, want) {
+			t.Fatalf("machine B Overlaps(%v) = %v, want %v", vb, got, want)
+		}
+
+		if !equalInts(sorted(ta.Overlaps(va)), sorted(tb.Overlaps(vb))) {
+			floatDiffers = true
+		}
+
+	}
+
+	// without fixed-point rounding the machines disagree, so the boundaries above are actually exercised
+	if !floatDiffers {
+		t.Fatal("float trees agreed on every query, boundary cases not exercised")
+	}
+
+}
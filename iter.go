@@ -0,0 +1,50 @@
+// MIT License
+//
+// Copyright (c) 2022 geozelot (André Siefken)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package boxtree
+
+// OverlapsBoxSeq is the iterator variant of the box-vs-box search;
+// lazily yields the index of each stored box intersecting the box given by its limits, along with its limits as {lower..., upper...}.
+//
+// The yielded limits Slice is reused between iterations; it must not be modified or retained beyond the current one.
+// Breaking out of the loop stops the traversal.
+//
+// The result has the underlying type of iter.Seq2[int, []float64], so it ranges directly with Go 1.23 and later
+// and is assignable to that type; on older toolchains call it with a yield function, returning false to stop.
+func (boT *BOXTree) OverlapsBoxSeq(lower, upper []float64) func(yield func(int, []float64) bool) {
+
+	return func(yield func(int, []float64) bool) {
+
+		lm := make([]float64, 4)
+
+		boT.traverseBox(lower, upper, func(cn int) bool {
+
+			copy(lm[:2], boT.lmts[2*cn])
+			copy(lm[2:], boT.lmts[2*cn+1])
+
+			return yield(boT.idxs[cn], lm)
+
+		})
+
+	}
+
+}
@@ -0,0 +1,51 @@
+package boxtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestOverlapsBoxSeq(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(33))
+	bxs := randomBoxes(rng, 1000, 100, 10)
+	tree := NewBOXTree(bxs)
+
+	for q := 0; q < 100; q++ {
+
+		lower := []float64{rng.Float64() * 100, rng.Float64() * 100}
+		upper := []float64{lower[0] + rng.Float64()*10, lower[1] + rng.Float64()*10}
+		got := []int{}
+
+		tree.OverlapsBoxSeq(lower, upper)(func(idx int, lm []float64) bool {
+
+			if l, u := bxs[idx].Limits(); lm[0] != l[0] || lm[1] != l[1] || lm[2] != u[0] || lm[3] != u[1] {
+				t.Fatalf("box %d: yielded limits %v, want %v %v", idx, lm, l, u)
+			}
+
+			got = append(got, idx)
+
+			return true
+
+		})
+
+		if want := sorted(tree.OverlapMatrix([][2][]float64{{lower, upper}})[0]); !equalInts(sorted(got), want) {
+			t.Fatalf("OverlapsBoxSeq(%v, %v) = %v, want %v", lower, upper, sorted(got), want)
+		}
+
+		n := 0
+
+		tree.OverlapsBoxSeq(lower, upper)(func(int, []float64) bool {
+
+			n++
+			return false
+
+		})
+
+		if n > 1 {
+			t.Fatalf("yield returning false: %d iterations", n)
+		}
+
+	}
+
+}
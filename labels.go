@@ -0,0 +1,89 @@
+// MIT License
+//
+// Copyright (c) 2022 geozelot (André Siefken)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package boxtree
+
+import (
+	"fmt"
+)
+
+// NewBOXTreeLabeled is the labeled initialization function;
+// creates the tree from the given Slice of Box, attaching the label at the same index to each box.
+//
+// Panics if the number of labels differs from the number of boxes; NewBOXTreeLabeledChecked returns an error instead.
+func NewBOXTreeLabeled(bxs []Box, labels []string, opts ...Option) *BOXTree {
+
+	if len(labels) != len(bxs) {
+		panic("boxtree: number of labels does not match number of boxes")
+	}
+
+	boT := NewBOXTree(bxs, opts...)
+	boT.lbls = labels
+
+	return boT
+
+}
+
+// NewBOXTreeLabeledChecked is the guarded variant of NewBOXTreeLabeled;
+// returns ErrDimensionMismatch if the number of labels differs from the number of boxes, otherwise behaves like NewBOXTreeChecked.
+func NewBOXTreeLabeledChecked(bxs []Box, labels []string, opts ...Option) (*BOXTree, error) {
+
+	if len(labels) != len(bxs) {
+		return nil, fmt.Errorf("%w: %d labels for %d boxes", ErrDimensionMismatch, len(labels), len(bxs))
+	}
+
+	boT, err := NewBOXTreeChecked(bxs, opts...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	boT.lbls = labels
+
+	return boT, nil
+
+}
+
+// Label is the label accessor; returns the label of the box with the given original index, or "" for unlabeled trees.
+func (boT *BOXTree) Label(idx int) string {
+
+	if boT.lbls == nil {
+		return ""
+	}
+
+	return boT.lbls[idx]
+
+}
+
+// OverlapsLabeled is the label variant of Overlaps;
+// traverses the tree and collects the labels of boxes that overlap with the given values.
+func (boT *BOXTree) OverlapsLabeled(vals []float64) []string {
+
+	res := []string{}
+
+	for _, idx := range boT.Overlaps(vals) {
+		res = append(res, boT.Label(idx))
+	}
+
+	return res
+
+}
@@ -0,0 +1,30 @@
+package boxtree
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewBOXTreeLabeledChecked(t *testing.T) {
+
+	bxs := []Box{NewRect(0, 0, 2, 2), NewRect(1, 1, 3, 3)}
+
+	if _, err := NewBOXTreeLabeledChecked(bxs, []string{"a"}); !errors.Is(err, ErrDimensionMismatch) {
+		t.Fatalf("NewBOXTreeLabeledChecked with 1 label for 2 boxes: err = %v, want ErrDimensionMismatch", err)
+	}
+
+	tree, err := NewBOXTreeLabeledChecked(bxs, []string{"a", "b"})
+
+	if err != nil {
+		t.Fatalf("NewBOXTreeLabeledChecked: %v", err)
+	}
+
+	if got := tree.OverlapsLabeled([]float64{2.5, 2.5}); len(got) != 1 || got[0] != "b" {
+		t.Fatalf("OverlapsLabeled = %v, want [b]", got)
+	}
+
+	if err := tree.RebuildChecked(bxs[:1]); !errors.Is(err, ErrDimensionMismatch) {
+		t.Fatalf("RebuildChecked with 1 box for 2 labels: err = %v, want ErrDimensionMismatch", err)
+	}
+
+}
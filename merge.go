@@ -0,0 +1,130 @@
+// MIT License
+//
+// Copyright (c) 2022 geozelot (André Siefken)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package boxtree
+
+import (
+	gosort "sort"
+)
+
+// Merge is the tree consolidation function;
+// creates a new balanced tree holding the boxes of both given trees.
+//
+// Indices of a are kept, indices of b are offset by the number of boxes a was built from,
+// so results reference the concatenation of both input Slices of Box. Boxes collapsed by WithDedup are not restored,
+// and the merged tree is built without any Options.
+func Merge(a, b *BOXTree) *BOXTree {
+
+	boT := BOXTree{size: a.size + b.size, cow: new(uint32)}
+
+	boT.idxs = make([]int, 0, len(a.idxs)+len(b.idxs))
+	boT.lmts = make([][]float64, 0, 2*(len(a.idxs)+len(b.idxs)))
+	boT.ags = make([]float64, 2*(len(a.idxs)+len(b.idxs)))
+
+	for _, t := range []struct {
+		tree *BOXTree
+		off  int
+	}{{a, 0}, {b, a.size}} {
+
+		for i, v := range t.tree.idxs {
+
+			boT.idxs = append(boT.idxs, v+t.off)
+			boT.lmts = append(boT.lmts, t.tree.lmts[2*i], t.tree.lmts[2*i+1])
+
+		}
+
+	}
+
+	sort(boT.lmts, boT.idxs, 0, 1, 0)
+	augment(boT.lmts, boT.ags, boT.idxs, 0, 1, 0)
+
+	return &boT
+
+}
+
+// Diff is the tree comparison function;
+// reports the boxes of new whose limits are not stored in old as added, and those of old not stored in new as removed.
+//
+// Boxes are equal if all their limits match exactly (==); shifted boxes are thus both removed and added.
+// Equal boxes are matched by count, surplus ones reported by their highest indices. added holds indices into the Slice of Box new was built from,
+// removed indices into that of old; both are sorted ascending, and boxes collapsed by WithDedup are taken into account.
+func Diff(old, new *BOXTree) (added, removed []int) {
+
+	ol, nw := old.entries(), new.entries()
+	added, removed = []int{}, []int{}
+
+	for k, idxs := range nw {
+
+		if n := len(ol[k]); n < len(idxs) {
+			added = append(added, idxs[n:]...)
+		}
+
+	}
+
+	for k, idxs := range ol {
+
+		if n := len(nw[k]); n < len(idxs) {
+			removed = append(removed, idxs[n:]...)
+		}
+
+	}
+
+	gosort.Ints(added)
+	gosort.Ints(removed)
+
+	return added, removed
+
+}
+
+// entries is the internal box set function of Diff; groups the ascending original indices of all boxes by their limits.
+func (boT *BOXTree) entries() map[[4]float64][]int {
+
+	ets := map[[4]float64][]int{}
+	kys := map[int][4]float64{}
+
+	for i, idx := range boT.idxs {
+
+		l, u := boT.lmts[2*i], boT.lmts[2*i+1]
+		k := [4]float64{l[0], l[1], u[0], u[1]}
+
+		ets[k] = append(ets[k], idx)
+
+		if boT.dups != nil {
+			kys[idx] = k
+		}
+
+	}
+
+	for _, grp := range boT.dups {
+
+		k := kys[grp[0]]
+		ets[k] = append(ets[k], grp[1:]...)
+
+	}
+
+	for _, idxs := range ets {
+		gosort.Ints(idxs)
+	}
+
+	return ets
+
+}
@@ -0,0 +1,102 @@
+package boxtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestMergeMatchesSeparateQueries(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(47))
+	as, bs := randomBoxes(rng, 700, 100, 10), randomBoxes(rng, 300, 50, 20)
+
+	for _, opts := range [][]Option{{}, {WithLeafSize(8)}} {
+
+		a, b := NewBOXTree(as, opts...), NewBOXTree(bs, opts...)
+		mrg := Merge(a, b)
+
+		if err := mrg.Validate(); err != nil {
+			t.Fatal(err)
+		}
+
+		for q := 0; q < 300; q++ {
+
+			vals := []float64{rng.Float64() * 110, rng.Float64() * 110}
+			want := a.Overlaps(vals)
+
+			for _, idx := range b.Overlaps(vals) {
+				want = append(want, idx+len(as))
+			}
+
+			if got, want := sorted(mrg.Overlaps(vals)), sorted(want); !equalInts(got, want) {
+				t.Fatalf("merged Overlaps(%v) = %v, want %v", vals, got, want)
+			}
+
+			if got, want := sorted(mrg.Overlaps(vals)), bruteOverlaps(append(append([]Box{}, as...), bs...), vals); !equalInts(got, want) {
+				t.Fatalf("merged Overlaps(%v) = %v, want %v", vals, got, want)
+			}
+
+		}
+
+		// queries on the inputs are unaffected by the merge
+		vals := []float64{25, 25}
+
+		if got, want := sorted(a.Overlaps(vals)), bruteOverlaps(as, vals); !equalInts(got, want) {
+			t.Fatalf("input Overlaps(%v) after Merge = %v, want %v", vals, got, want)
+		}
+
+	}
+
+}
+
+func TestDiffShiftedSets(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(48))
+	old := randomBoxes(rng, 10, 100, 10)
+	l, u := old[9].Limits()
+	old = append(old, &testBox{l, u})
+
+	// new keeps copies of old boxes 5-9, holds old box 2 shifted by half a unit and two unrelated boxes
+	nw := []Box{}
+
+	for _, bx := range old[5:10] {
+
+		l, u := bx.Limits()
+		nw = append(nw, &testBox{[]float64{l[0], l[1]}, []float64{u[0], u[1]}})
+
+	}
+
+	l, u = old[2].Limits()
+	nw = append(nw, &testBox{[]float64{l[0] + 0.5, l[1]}, []float64{u[0] + 0.5, u[1]}})
+	nw = append(nw, randomBoxes(rng, 2, 100, 10)...)
+
+	for _, opts := range [][]Option{{}, {WithDedup()}} {
+
+		added, removed := Diff(NewBOXTree(old, opts...), NewBOXTree(nw, opts...))
+
+		if want := []int{5, 6, 7}; !equalInts(added, want) {
+			t.Fatalf("added = %v, want %v", added, want)
+		}
+
+		// the surplus copy of box 9 is reported by its higher index
+		if want := []int{0, 1, 2, 3, 4, 10}; !equalInts(removed, want) {
+			t.Fatalf("removed = %v, want %v", removed, want)
+		}
+
+		added, removed = Diff(NewBOXTree(nw, opts...), NewBOXTree(old, opts...))
+
+		if want := []int{0, 1, 2, 3, 4, 10}; !equalInts(added, want) {
+			t.Fatalf("reversed added = %v, want %v", added, want)
+		}
+
+		if want := []int{5, 6, 7}; !equalInts(removed, want) {
+			t.Fatalf("reversed removed = %v, want %v", removed, want)
+		}
+
+		if added, removed := Diff(NewBOXTree(old, opts...), NewBOXTree(old, opts...)); len(added) != 0 || len(removed) != 0 {
+			t.Fatalf("Diff of equal sets = %v, %v, want none", added, removed)
+		}
+
+	}
+
+}
@@ -0,0 +1,247 @@
+// MIT License
+//
+// Copyright (c) 2022 geozelot (André Siefken)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package boxtree
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// NDBOXTree is the N-dimensional package object;
+// holds Slice of reference indices and the respective box limits, packed flat per node as dims lower followed by dims upper limits.
+type NDBOXTree struct {
+	idxs []int
+	lmts []float64
+	ags  []float64
+	dims int
+}
+
+// NewBOXTreeNDFlat is the N-dimensional, flat-packed initialization function;
+// creates the tree from n boxes packed in data as 2*dims values each, dims lower limits followed by dims upper limits.
+//
+// data is copied into a single Slice, so the build allocates independently of n and dims; the split axis cycles through all dims axes.
+// Panics if dims is below 1 or data holds fewer than n boxes.
+func NewBOXTreeNDFlat(data []float64, n, dims int) *NDBOXTree {
+
+	if dims < 1 || n < 0 || len(data) < 2*dims*n {
+		panic("boxtree: flat data does not hold n boxes of dims dimensions")
+	}
+
+	boT := NDBOXTree{idxs: make([]int, n), lmts: make([]float64, 2*dims*n), ags: make([]float64, 2*n), dims: dims}
+
+	copy(boT.lmts, data)
+
+	for i := range boT.idxs {
+		boT.idxs[i] = i
+	}
+
+	sortND(boT.lmts, boT.idxs, 0, dims)
+	augmentND(boT.lmts, boT.ags, boT.idxs, 0, dims)
+
+	return &boT
+
+}
+
+// NewBOXTreeNDFlatChecked is the guarded variant of NewBOXTreeNDFlat;
+// returns ErrDimensionMismatch instead of panicking if dims is below 1 or data holds fewer than n boxes.
+func NewBOXTreeNDFlatChecked(data []float64, n, dims int) (*NDBOXTree, error) {
+
+	if dims < 1 || n < 0 || len(data) < 2*dims*n {
+		return nil, fmt.Errorf("%w: %d values for %d boxes of %d dimensions", ErrDimensionMismatch, len(data), n, dims)
+	}
+
+	return NewBOXTreeNDFlat(data, n, dims), nil
+
+}
+
+// Dims returns the number of dimensions of the tree's boxes.
+func (boT *NDBOXTree) Dims() int {
+
+	return boT.dims
+
+}
+
+// Len returns the number of boxes stored in the tree.
+func (boT *NDBOXTree) Len() int {
+
+	return len(boT.idxs)
+
+}
+
+// RawArrays is the zero-copy export function for foreign traversals (e.g. via cgo);
+// returns the internal Slices of reference indices, packed box limits and augmented limits along with the number of dimensions.
+//
+// Node i of the implicit tree references the original box idxs[i] and holds its limits at lmts[2*dims*i : 2*dims*(i+1)],
+// dims lower limits followed by dims upper limits. The root of a range [lb, rb] is its node ceil((lb+rb)/2), splitting it into
+// [lb, root-1] and [root+1, rb]; the root of the full range [0, len(idxs)-1] splits on axis 0, each level below on the next axis (modulo dims),
+// with no lower limit on the split axis left of the root exceeding and none right of it falling below that of the root.
+// ags holds 2 values per node: ags[2*i] is the maximum upper and ags[2*i+1] the minimum lower limit on the split axis
+// over all boxes of the range rooted at node i, the root included.
+//
+// All Slices are read-only: mutating them corrupts the tree.
+func (boT *NDBOXTree) RawArrays() (idxs []int, lmts, ags []float64, dims int) {
+
+	return boT.idxs, boT.lmts, boT.ags, boT.dims
+
+}
+
+// Overlaps is the main entry point for N-dimensional box searches;
+// traverses the tree and collects boxes that overlap with the given values, one per dimension.
+func (boT *NDBOXTree) Overlaps(vals []float64) []int {
+
+	buf := buffers.Get().(*buffer)
+	res := buf.res[:0]
+	d := boT.dims
+
+	descend(buf, len(boT.idxs), 0, 1, d, func(lb, rb, cn, ax int) (left, right, ok bool) {
+
+		if vals[ax] < boT.ags[2*cn+1] {
+			return false, false, true
+		}
+
+		nd := boT.lmts[2*d*cn : 2*d*cn+2*d]
+		left, right = vals[ax] <= boT.ags[2*cn], nd[ax] <= vals[ax]
+
+		if right {
+
+			hit := true
+
+			for a := 0; a < d && hit; a++ {
+				hit = nd[a] <= vals[a] && vals[a] <= nd[d+a]
+			}
+
+			if hit {
+				res = append(res, boT.idxs[cn])
+			}
+
+		}
+
+		return left, right, true
+
+	})
+
+	out := make([]int, len(res))
+	copy(out, res)
+
+	buf.res = res[:0]
+	buffers.Put(buf)
+
+	return out
+
+}
+
+// augmentND is an internal utility function, adding maximum upper and minimum lower value of all child nodes to the current node.
+func augmentND(lmts, ags []float64, idxs []int, ax int, d int) {
+
+	if len(idxs) < 1 {
+		return
+	}
+
+	max, min := math.Inf(-1), math.Inf(1)
+
+	for i := range idxs {
+
+		max = math.Max(max, lmts[2*d*i+d+ax])
+		min = math.Min(min, lmts[2*d*i+ax])
+
+	}
+
+	r := len(idxs) >> 1
+
+	ags[2*r] = max
+	ags[2*r+1] = min
+
+	augmentND(lmts[:2*d*r], ags[:2*r], idxs[:r], (ax+1)%d, d)
+	augmentND(lmts[2*d*(r+1):], ags[2*(r+1):], idxs[r+1:], (ax+1)%d, d)
+
+}
+
+// sortND is an internal utility function, ordering the tree by lowest limits using Random Pivot QuickSelect;
+// places the median of each range on its midpoint node, cycling the axis per level.
+func sortND(lmts []float64, idxs []int, ax int, d int) {
+
+	if len(idxs) < 2 {
+		return
+	}
+
+	k := len(idxs) >> 1
+	lb, rb := 0, len(idxs)-1
+
+	for lb < rb {
+
+		swapND(lmts, idxs, lb+rand.Int()%(rb-lb+1), rb, d)
+
+		pv := lmts[2*d*rb+ax]
+		l, e := lb, lb
+
+		for i := lb; i < rb; i++ {
+
+			if lmts[2*d*i+ax] < pv {
+
+				swapND(lmts, idxs, i, e, d)
+				swapND(lmts, idxs, e, l, d)
+
+				l++
+				e++
+
+			} else if lmts[2*d*i+ax] == pv {
+
+				swapND(lmts, idxs, i, e, d)
+				e++
+
+			}
+
+		}
+
+		swapND(lmts, idxs, e, rb, d)
+
+		if k < l {
+			rb = l - 1
+		} else if k > e {
+			lb = e + 1
+		} else {
+			break
+		}
+
+	}
+
+	sortND(lmts[:2*d*k], idxs[:k], (ax+1)%d, d)
+	sortND(lmts[2*d*(k+1):], idxs[k+1:], (ax+1)%d, d)
+
+}
+
+// swapND is an internal utility function, exchanging the packed limits of two nodes along with their reference indices.
+func swapND(lmts []float64, idxs []int, i, j int, d int) {
+
+	if i == j {
+		return
+	}
+
+	idxs[i], idxs[j] = idxs[j], idxs[i]
+
+	for a := 0; a < 2*d; a++ {
+		lmts[2*d*i+a], lmts[2*d*j+a] = lmts[2*d*j+a], lmts[2*d*i+a]
+	}
+
+}
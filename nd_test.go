@@ -0,0 +1,156 @@
+package boxtree
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func TestNDOverlapsMatchesBruteForce(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(21))
+
+	for _, d := range []int{1, 2, 3, 5} {
+
+		n := 1500
+		data := make([]float64, 2*d*n)
+
+		for i := 0; i < n; i++ {
+
+			for a := 0; a < d; a++ {
+
+				data[2*d*i+a] = rng.Float64() * 100
+				data[2*d*i+d+a] = data[2*d*i+a] + rng.Float64()*20
+
+			}
+
+		}
+
+		tree := NewBOXTreeNDFlat(data, n, d)
+
+		for q := 0; q < 200; q++ {
+
+			vals := make([]float64, d)
+
+			for a := range vals {
+				vals[a] = rng.Float64() * 110
+			}
+
+			want := []int{}
+
+			for i := 0; i < n; i++ {
+
+				hit := true
+
+				for a := 0; a < d && hit; a++ {
+					hit = data[2*d*i+a] <= vals[a] && vals[a] <= data[2*d*i+d+a]
+				}
+
+				if hit {
+					want = append(want, i)
+				}
+
+			}
+
+			if got := sorted(tree.Overlaps(vals)); !equalInts(got, want) {
+				t.Fatalf("dims %d, Overlaps(%v) = %v, want %v", d, vals, got, want)
+			}
+
+		}
+
+	}
+
+}
+
+func TestNewBOXTreeNDFlatChecked(t *testing.T) {
+
+	if _, err := NewBOXTreeNDFlatChecked(make([]float64, 11), 2, 3); !errors.Is(err, ErrDimensionMismatch) {
+		t.Fatalf("short data: err = %v, want ErrDimensionMismatch", err)
+	}
+
+	if _, err := NewBOXTreeNDFlatChecked(nil, 0, 0); !errors.Is(err, ErrDimensionMismatch) {
+		t.Fatalf("zero dims: err = %v, want ErrDimensionMismatch", err)
+	}
+
+	tree, err := NewBOXTreeNDFlatChecked([]float64{0, 0, 1, 1}, 1, 2)
+
+	if err != nil || tree.Len() != 1 || len(tree.Overlaps([]float64{0.5, 0.5})) != 1 {
+		t.Fatalf("valid data: tree %v, err %v", tree, err)
+	}
+
+	if res := NewBOXTreeNDFlat(nil, 0, 2).Overlaps([]float64{0, 0}); len(res) != 0 {
+		t.Fatalf("empty tree: Overlaps = %v, want none", res)
+	}
+
+}
+
+func TestNDRawArraysTraversal(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(22))
+	n, d := 800, 3
+	data := make([]float64, 2*d*n)
+
+	for i := range data {
+		data[i] = rng.Float64() * 100
+	}
+
+	for i := 0; i < n; i++ {
+
+		for a := 0; a < d; a++ {
+			data[2*d*i+d+a] = data[2*d*i+a] + rng.Float64()*15
+		}
+
+	}
+
+	tree := NewBOXTreeNDFlat(data, n, d)
+	idxs, lmts, ags, dims := tree.RawArrays()
+
+	if dims != d || len(idxs) != n || len(lmts) != 2*d*n || len(ags) != 2*n {
+		t.Fatalf("RawArrays sizes: %d idxs, %d lmts, %d ags, %d dims", len(idxs), len(lmts), len(ags), dims)
+	}
+
+	for q := 0; q < 100; q++ {
+
+		vals := []float64{rng.Float64() * 110, rng.Float64() * 110, rng.Float64() * 110}
+		res := []int{}
+
+		var visit func(lb, rb, ax int)
+		visit = func(lb, rb, ax int) {
+
+			if lb > rb {
+				return
+			}
+
+			cn := (lb + rb + 1) / 2
+
+			if vals[ax] < ags[2*cn+1] || ags[2*cn] < vals[ax] {
+				return
+			}
+
+			hit := true
+
+			for a := 0; a < dims && hit; a++ {
+				hit = lmts[2*dims*cn+a] <= vals[a] && vals[a] <= lmts[2*dims*cn+dims+a]
+			}
+
+			if hit {
+				res = append(res, idxs[cn])
+			}
+
+			visit(lb, cn-1, (ax+1)%dims)
+
+			if lmts[2*dims*cn+ax] <= vals[ax] {
+				visit(cn+1, rb, (ax+1)%dims)
+			}
+
+		}
+
+		visit(0, n-1, 0)
+
+		if got, want := sorted(res), sorted(tree.Overlaps(vals)); !equalInts(got, want) {
+			t.Fatalf("raw traversal of %v = %v, want %v", vals, got, want)
+		}
+
+	}
+
+}
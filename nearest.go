@@ -0,0 +1,165 @@
+// MIT License
+//
+// Copyright (c) 2022 geozelot (André Siefken)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package boxtree
+
+import (
+	"math"
+)
+
+// Nearest is the main entry point for proximity searches;
+// traverses the tree and returns the box closest to the given values along with its Euclidean distance (0 if overlapping).
+//
+// Returns -1 and +Inf for an empty tree.
+func (boT *BOXTree) Nearest(vals []float64) (idx int, dist float64) {
+
+	return boT.NearestFunc(vals, nil)
+
+}
+
+// NearestFunc is the filtered variant of Nearest;
+// traverses the tree and returns the closest box for which accept returns true (all boxes if accept is nil).
+//
+// Subtrees are pruned by their augmented limits only, so rejected boxes never narrow the search.
+// Returns -1 and +Inf if no box is accepted.
+func (boT *BOXTree) NearestFunc(vals []float64, accept func(idx int) bool) (idx int, dist float64) {
+
+	idx, dist = -1, math.Inf(1)
+
+	descend(nil, len(boT.idxs), boT.ax0, boT.step(), 2, func(lb, rb, cn, ax int) (left, right, ok bool) {
+
+		if ag := boT.ags[2*cn : 2*cn+2]; gap(vals[ax], ag[1], ag[0]) > dist {
+			return false, false, true
+		}
+
+		if rb-lb < boT.leaf {
+
+			for i := lb; i <= rb; i++ {
+
+				if d := distance(boT.lmts[2*i], boT.lmts[2*i+1], vals); d < dist && (accept == nil || accept(boT.idxs[i])) {
+					idx, dist = boT.idxs[i], d
+				}
+
+			}
+
+			return false, false, true
+
+		}
+
+		l := boT.lmts[2*cn]
+
+		if d := distance(l, boT.lmts[2*cn+1], vals); d < dist && (accept == nil || accept(boT.idxs[cn])) {
+			idx, dist = boT.idxs[cn], d
+		}
+
+		return true, l[ax]-vals[ax] <= dist, true
+
+	})
+
+	return idx, dist
+
+}
+
+// NearestOverlapping is the containment variant of Nearest;
+// returns the overlapping box whose center is closest to the given values along with that Euclidean center distance.
+//
+// Only boxes overlapping the values (inclusive) are considered, tracking the best match during traversal without collecting all;
+// ties are resolved by the lowest original index. Returns -1 and +Inf if no box overlaps.
+func (boT *BOXTree) NearestOverlapping(vals []float64) (idx int, dist float64) {
+
+	idx, dist = -1, math.Inf(1)
+
+	boT.traverse(vals, func(cn int) bool {
+
+		l, u := boT.lmts[2*cn], boT.lmts[2*cn+1]
+		d := math.Hypot((l[0]+u[0])/2.0-vals[0], (l[1]+u[1])/2.0-vals[1])
+
+		if d < dist || d == dist && boT.idxs[cn] < idx {
+			idx, dist = boT.idxs[cn], d
+		}
+
+		return true
+
+	})
+
+	return idx, dist
+
+}
+
+// distance is an internal utility function, calculating the Euclidean distance between the values and the given limits.
+func distance(l, u, vals []float64) float64 {
+
+	return math.Hypot(gap(vals[0], l[0], u[0]), gap(vals[1], l[1], u[1]))
+
+}
+
+// gap is an internal utility function, calculating the distance between a value and the interval [lo, hi].
+func gap(val, lo, hi float64) float64 {
+
+	if val < lo {
+		return lo - val
+	}
+
+	if val > hi {
+		return val - hi
+	}
+
+	return 0
+
+}
+
+// WithinEllipse is the anisotropic proximity query;
+// collects all boxes with at least one point within the axis-aligned ellipse around center with the given per-axis radii, boundary included.
+//
+// Subtrees are pruned by the ellipse's bounding box; candidates are then tested exactly in coordinates scaled by the radii.
+// A radius of zero degenerates the ellipse to a line segment on the other axis.
+func (boT *BOXTree) WithinEllipse(center, radii []float64) []int {
+
+	res := []int{}
+
+	lower := []float64{center[0] - radii[0], center[1] - radii[1]}
+	upper := []float64{center[0] + radii[0], center[1] + radii[1]}
+
+	boT.traverseBox(lower, upper, func(cn int) bool {
+
+		l, u := boT.lmts[2*cn], boT.lmts[2*cn+1]
+		sum := 0.0
+
+		for ax := 0; ax < 2; ax++ {
+
+			if g := gap(center[ax], l[ax], u[ax]); g > 0 {
+				sum += (g / radii[ax]) * (g / radii[ax])
+			}
+
+		}
+
+		if sum <= 1 {
+			res = append(res, boT.idxs[cn])
+		}
+
+		return true
+
+	})
+
+	return res
+
+}
@@ -0,0 +1,233 @@
+package boxtree
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestNearestMatchesBruteForce(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(5))
+
+	for _, lf := range []int{0, 8} {
+
+		bxs := randomBoxes(rng, 2000, 100, 5)
+		tree := NewBOXTree(bxs, WithLeafSize(lf))
+
+		for q := 0; q < 500; q++ {
+
+			vals := []float64{rng.Float64()*140 - 20, rng.Float64()*140 - 20}
+			want := math.Inf(1)
+
+			for _, bx := range bxs {
+
+				l, u := bx.Limits()
+				want = math.Min(want, distance(l, u, vals))
+
+			}
+
+			if idx, dist := tree.Nearest(vals); dist != want {
+				t.Fatalf("leaf %d, Nearest(%v) = %d, %g, want distance %g", lf, vals, idx, dist, want)
+			}
+
+		}
+
+	}
+
+}
+
+func TestNearestFuncRejectsAll(t *testing.T) {
+
+	tree := NewBOXTree(randomBoxes(rand.New(rand.NewSource(6)), 100, 100, 5))
+
+	if idx, dist := tree.NearestFunc([]float64{50, 50}, func(int) bool { return false }); idx != -1 || !math.IsInf(dist, 1) {
+		t.Fatalf("NearestFunc = %d, %g, want -1, +Inf", idx, dist)
+	}
+
+}
+
+func TestNearestFuncSkipsRejected(t *testing.T) {
+
+	bxs := []Box{
+		&testBox{[]float64{10, 0}, []float64{11, 1}},
+		&testBox{[]float64{1, 0}, []float64{2, 1}},
+		&testBox{[]float64{0, 4}, []float64{1, 5}},
+		&testBox{[]float64{-20, -20}, []float64{-19, -19}},
+	}
+
+	for _, lf := range []int{0, 8} {
+
+		tree := NewBOXTree(bxs, WithLeafSize(lf))
+		vals := []float64{0, 0.5}
+
+		if idx, dist := tree.Nearest(vals); idx != 1 || dist != 1 {
+			t.Fatalf("leaf %d, Nearest = %d, %g, want 1, 1", lf, idx, dist)
+		}
+
+		// rejecting the geometrically nearest box yields the second nearest
+		if idx, dist := tree.NearestFunc(vals, func(idx int) bool { return idx != 1 }); idx != 2 || dist != 3.5 {
+			t.Fatalf("leaf %d, NearestFunc = %d, %g, want 2, 3.5", lf, idx, dist)
+		}
+
+	}
+
+	rng := rand.New(rand.NewSource(49))
+
+	for _, lf := range []int{0, 8} {
+
+		bxs := randomBoxes(rng, 2000, 100, 5)
+		tree := NewBOXTree(bxs, WithLeafSize(lf))
+		odd := func(idx int) bool { return idx%2 == 1 }
+
+		for q := 0; q < 500; q++ {
+
+			vals := []float64{rng.Float64()*140 - 20, rng.Float64()*140 - 20}
+			want := math.Inf(1)
+
+			for i, bx := range bxs {
+
+				if l, u := bx.Limits(); odd(i) {
+					want = math.Min(want, distance(l, u, vals))
+				}
+
+			}
+
+			if idx, dist := tree.NearestFunc(vals, odd); dist != want || !odd(idx) {
+				t.Fatalf("leaf %d, NearestFunc(%v) = %d, %g, want odd index at distance %g", lf, vals, idx, dist, want)
+			}
+
+		}
+
+	}
+
+}
+
+func BenchmarkNearest(b *testing.B) {
+
+	rng := rand.New(rand.NewSource(4))
+	tree := NewBOXTree(randomBoxes(rng, 100000, 1000, 10))
+
+	qs := make([][]float64, 1024)
+
+	for i := range qs {
+		qs[i] = []float64{rng.Float64() * 1000, rng.Float64() * 1000}
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		tree.Nearest(qs[i%len(qs)])
+	}
+
+}
+
+func TestWithinEllipseNarrowEnd(t *testing.T) {
+
+	tree := NewBOXTree([]Box{
+		// clips the narrow end of the ellipse around the x axis
+		&testBox{[]float64{9.5, -0.1}, []float64{12, 0.1}},
+		// touches its tip exactly
+		&testBox{[]float64{10, -1}, []float64{11, 1}},
+		// just beyond the tip
+		&testBox{[]float64{10.01, -1}, []float64{11, 1}},
+		// within the bounding box of the ellipse, but off its narrow end
+		&testBox{[]float64{9.9, 0.2}, []float64{11, 1}},
+		&testBox{[]float64{9, 0.9}, []float64{11, 2}},
+		// containing the center
+		&testBox{[]float64{-1, -5}, []float64{1, 5}},
+	})
+
+	if got, want := sorted(tree.WithinEllipse([]float64{0, 0}, []float64{10, 1})), []int{0, 1, 5}; !equalInts(got, want) {
+		t.Fatalf("WithinEllipse = %v, want %v", got, want)
+	}
+
+	rng := rand.New(rand.NewSource(64))
+	bxs := randomBoxes(rng, 1000, 100, 5)
+	tree = NewBOXTree(bxs)
+
+	for q := 0; q < 300; q++ {
+
+		center, radii := []float64{rng.Float64() * 100, rng.Float64() * 100}, []float64{rng.Float64() * 20, rng.Float64() * 3}
+		want := []int{}
+
+		for i, bx := range bxs {
+
+			// the nearest point of the box to the center, scaled by the radii
+			l, u := bx.Limits()
+			dx, dy := gap(center[0], l[0], u[0])/radii[0], gap(center[1], l[1], u[1])/radii[1]
+
+			if dx*dx+dy*dy <= 1 {
+				want = append(want, i)
+			}
+
+		}
+
+		if got := sorted(tree.WithinEllipse(center, radii)); !equalInts(got, want) {
+			t.Fatalf("WithinEllipse(%v, %v) = %v, want %v", center, radii, got, want)
+		}
+
+	}
+
+}
+
+func TestNearestOverlappingCenters(t *testing.T) {
+
+	tree := NewBOXTree([]Box{
+		&testBox{[]float64{0, 0}, []float64{10, 10}},
+		&testBox{[]float64{2, 2}, []float64{4, 4}},
+		&testBox{[]float64{3, 1}, []float64{9, 5}},
+		&testBox{[]float64{20, 20}, []float64{21, 21}},
+		&testBox{[]float64{2, 2}, []float64{4, 4}},
+	})
+
+	for _, tc := range []struct {
+		vals []float64
+		idx  int
+		dist float64
+	}{
+		// centers (5, 5), (3, 3) and (6, 3); the copies of box 1 tie, resolved by the lower index
+		{[]float64{3, 3}, 1, 0},
+		// tied with box 1 at the same distance
+		{[]float64{4, 4}, 0, math.Sqrt2},
+		{[]float64{6, 4}, 2, 1},
+		{[]float64{5, 6}, 0, 1},
+		// box 3 has the closest center overall but does not overlap
+		{[]float64{10, 10}, 0, 5 * math.Sqrt2},
+		{[]float64{15, 15}, -1, math.Inf(1)},
+	} {
+
+		idx, dist := tree.NearestOverlapping(tc.vals)
+
+		if idx != tc.idx || !(dist == tc.dist || math.Abs(dist-tc.dist) <= 1e-12) {
+			t.Fatalf("NearestOverlapping(%v) = %d, %v, want %d, %v", tc.vals, idx, dist, tc.idx, tc.dist)
+		}
+
+	}
+
+	rng := rand.New(rand.NewSource(84))
+	bxs := randomBoxes(rng, 1000, 100, 10)
+	tree = NewBOXTree(bxs, WithLeafSize(8))
+
+	for q := 0; q < 300; q++ {
+
+		vals := []float64{rng.Float64() * 110, rng.Float64() * 110}
+		want, best := -1, math.Inf(1)
+
+		for _, i := range bruteOverlaps(bxs, vals) {
+
+			l, u := bxs[i].Limits()
+
+			if d := math.Hypot((l[0]+u[0])/2.0-vals[0], (l[1]+u[1])/2.0-vals[1]); d < best {
+				want, best = i, d
+			}
+
+		}
+
+		if idx, dist := tree.NearestOverlapping(vals); idx != want || dist != best {
+			t.Fatalf("NearestOverlapping(%v) = %d, %v, want %d, %v", vals, idx, dist, want, best)
+		}
+
+	}
+
+}
@@ -0,0 +1,153 @@
+// MIT License
+//
+// Copyright (c) 2022 geozelot (André Siefken)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package boxtree
+
+import (
+	"math"
+)
+
+// Node is the cursor type for manual tree walking; references a single node of the flat tree layout.
+//
+// Nodes follow the implicit structure of the tree, with each range split at its ceil midpoint, and are only valid until the tree is rebuilt.
+// Summing the areas of all leaves, for example:
+//
+//	var sum func(nd *boxtree.Node) float64
+//
+//	sum = func(nd *boxtree.Node) float64 {
+//
+//		if nd == nil {
+//			return 0
+//		}
+//
+//		if l, r := nd.Left(), nd.Right(); l != nil || r != nil {
+//			return sum(l) + sum(r)
+//		}
+//
+//		lower, upper := nd.Limits()
+//		return (upper[0] - lower[0]) * (upper[1] - lower[1])
+//
+//	}
+//
+//	total := sum(tree.Root())
+type Node struct {
+	boT    *BOXTree
+	lb, rb int
+	ax, ag int
+}
+
+// Root is the cursor entry point; returns the root node of the tree, or nil if the tree is empty.
+func (boT *BOXTree) Root() *Node {
+
+	return boT.node(0, len(boT.idxs)-1, boT.ax0, -1)
+
+}
+
+// node is the internal Node constructor; returns nil for empty ranges,
+// and keeps the augmented limits of an enclosing leaf bucket at ag, if any.
+func (boT *BOXTree) node(lb, rb, ax, ag int) *Node {
+
+	if lb > rb {
+		return nil
+	}
+
+	if ag < 0 {
+
+		ag = int(math.Ceil(float64(lb+rb) / 2.0))
+
+		if rb-lb >= boT.leaf {
+			return &Node{boT, lb, rb, ax, -1}
+		}
+
+	}
+
+	return &Node{boT, lb, rb, ax, ag}
+
+}
+
+// pos is the internal position function; returns the position of the node within the flat tree layout.
+func (nd *Node) pos() int {
+
+	return int(math.Ceil(float64(nd.lb+nd.rb) / 2.0))
+
+}
+
+// Left returns the root of the left subtree, or nil if there is none.
+func (nd *Node) Left() *Node {
+
+	return nd.boT.node(nd.lb, nd.pos()-1, (nd.ax+nd.boT.step())%2, nd.ag)
+
+}
+
+// Right returns the root of the right subtree, or nil if there is none.
+func (nd *Node) Right() *Node {
+
+	return nd.boT.node(nd.pos()+1, nd.rb, (nd.ax+nd.boT.step())%2, nd.ag)
+
+}
+
+// Index returns the original index of the box stored at the node.
+func (nd *Node) Index() int {
+
+	return nd.boT.idxs[nd.pos()]
+
+}
+
+// Limits returns the lower and upper limits of the box stored at the node.
+func (nd *Node) Limits() (Lower, Upper []float64) {
+
+	p := nd.pos()
+
+	return nd.boT.lmts[2*p], nd.boT.lmts[2*p+1]
+
+}
+
+// Axis returns the split axis of the node's level.
+func (nd *Node) Axis() int {
+
+	return nd.ax
+
+}
+
+// Max returns the maximum upper limit on the node's axis of all boxes in its subtree;
+// within a leaf bucket (see WithLeafSize), that of the whole bucket, on the bucket's axis.
+func (nd *Node) Max() float64 {
+
+	if nd.ag >= 0 {
+		return nd.boT.ags[2*nd.ag]
+	}
+
+	return nd.boT.ags[2*nd.pos()]
+
+}
+
+// Min returns the minimum lower limit on the node's axis of all boxes in its subtree;
+// within a leaf bucket (see WithLeafSize), that of the whole bucket, on the bucket's axis.
+func (nd *Node) Min() float64 {
+
+	if nd.ag >= 0 {
+		return nd.boT.ags[2*nd.ag+1]
+	}
+
+	return nd.boT.ags[2*nd.pos()+1]
+
+}
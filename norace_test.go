@@ -0,0 +1,7 @@
+//go:build !race
+// +build !race
+
+package boxtree
+
+// raceEnabled reports whether tests run under the race detector, which randomly drops sync.Pool items and thus skews allocation counts.
+const raceEnabled = false
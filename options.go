@@ -0,0 +1,136 @@
+// MIT License
+//
+// Copyright (c) 2022 geozelot (André Siefken)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package boxtree
+
+// Option is the configuration type accepted by NewBOXTree(); applied to the tree before construction.
+type Option func(boT *BOXTree)
+
+// WithDedup is the deduplication Option;
+// collapses boxes with exactly equal limits into a single node, reporting the collapsed groups via Duplicates().
+//
+// Queries then return only the lowest original index of each group, never the other members.
+func WithDedup() Option {
+
+	return func(boT *BOXTree) {
+		boT.dedup = true
+	}
+
+}
+
+// WithPresorted is the sort skipping Option;
+// trusts the given Slice of Box to already be in tree layout (as produced by SortByAxis on axis 0) and only runs the augmentation.
+//
+// Input merely sorted by lower limit is not sufficient. NewBOXTree and Rebuild trust the layout, and misordered input silently
+// yields wrong query results; NewBOXTreeChecked and RebuildChecked validate it and return ErrUnsorted instead.
+// WithDedup is ignored under this Option, as collapsing boxes would break the given layout.
+func WithPresorted() Option {
+
+	return func(boT *BOXTree) {
+		boT.presorted = true
+	}
+
+}
+
+// WithColumnar is the structure-of-arrays Option;
+// additionally stores lower and upper limits per axis in contiguous Slices, at the cost of a second copy of all limits.
+//
+// Overlaps, HitCounts and OverlapMatrix on trees of up to 64 boxes then scan these columns linearly instead of traversing the tree,
+// returning the same boxes (in node order); all other queries traverse as usual.
+func WithColumnar() Option {
+
+	return func(boT *BOXTree) {
+		boT.columnar = true
+	}
+
+}
+
+// WithMaxDepth is the depth limiting Option for untrusted input;
+// caps the number of tree levels accepted by NewBOXTreeChecked and traversed by OverlapsChecked.
+func WithMaxDepth(depth int) Option {
+
+	return func(boT *BOXTree) {
+		boT.depth = depth
+	}
+
+}
+
+// WithLeafSize is the bucketing Option for large, dense inputs;
+// stops splitting at ranges of up to n boxes, which are then scanned linearly instead of traversed.
+//
+// Trades a shallower tree and faster builds for a few extra comparisons per visited bucket; n below 2 keeps the default.
+func WithLeafSize(n int) Option {
+
+	return func(boT *BOXTree) {
+		boT.leaf = n
+	}
+
+}
+
+// WithAllocator is the storage placement Option for constrained environments;
+// obtains the index and augmented limit backing storage of NewBOXTree and Rebuild from the given functions instead of make.
+//
+// Either function may be nil to keep make for its type; returned Slices must have at least the requested length.
+// Box limits are referenced, not copied, and the Slice of limit references itself is still allocated by the runtime.
+func WithAllocator(alloc func(n int) []float64, allocInt func(n int) []int) Option {
+
+	return func(boT *BOXTree) {
+		boT.alloc, boT.allocInt = alloc, allocInt
+	}
+
+}
+
+// WithPrecomputedAreas is the area caching Option;
+// computes the area of each box once at build time, turning area based queries (OverlapsByArea, OverlapsAreaBudget, Area) into lookups.
+//
+// Costs one float64 per box; areas follow UpdateLimits and Reaugment.
+func WithPrecomputedAreas() Option {
+
+	return func(boT *BOXTree) {
+		boT.areas = true
+	}
+
+}
+
+// WithRebuildThreshold is the maintenance tuning Option;
+// sets the share of updated boxes (relative to the stored ones) above which NeedsRebuild reports true; 0 or below keeps the default of 0.25.
+func WithRebuildThreshold(share float64) Option {
+
+	return func(boT *BOXTree) {
+		boT.rebuild = share
+	}
+
+}
+
+// WithNaNUnbounded is the partially defined box Option;
+// treats a NaN lower limit as -Inf and a NaN upper limit as +Inf, so such boxes extend infinitely and match any value on that axis.
+//
+// Limits are substituted once at build time, so queries and augmentation run as usual; boxes holding NaN are stored as copies,
+// hence in-place edits of their limits are not seen by Reaugment. Without this Option, NaN limits never match.
+// NewBOXTreeChecked, RebuildChecked and UpdateLimits accept NaN limits under this Option, and still reject ±Inf.
+func WithNaNUnbounded() Option {
+
+	return func(boT *BOXTree) {
+		boT.unbounded = true
+	}
+
+}
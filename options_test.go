@@ -0,0 +1,338 @@
+package boxtree
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestColumnarMatchesTraversal(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(30))
+
+	for _, n := range []int{0, 1, 64, 65} {
+
+		bxs := randomBoxes(rng, n, 100, 20)
+		col, std := NewBOXTree(bxs, WithColumnar()), NewBOXTree(bxs)
+
+		points, queries := make([][]float64, 200), make([][2][]float64, 200)
+
+		for i := range points {
+
+			points[i] = []float64{rng.Float64() * 110, rng.Float64() * 110}
+			queries[i] = [2][]float64{points[i], {points[i][0] + rng.Float64()*10, points[i][1] + rng.Float64()*10}}
+
+			if got, want := sorted(col.Overlaps(points[i])), bruteOverlaps(bxs, points[i]); !equalInts(got, want) {
+				t.Fatalf("n %d: Overlaps(%v) = %v, want %v", n, points[i], got, want)
+			}
+
+		}
+
+		if got, want := col.HitCounts(points), std.HitCounts(points); !equalInts(got, want) {
+			t.Fatalf("n %d: HitCounts = %v, want %v", n, got, want)
+		}
+
+		for i, row := range col.OverlapMatrix(queries) {
+
+			if got, want := sorted(row), sorted(std.OverlapMatrix(queries[i : i+1])[0]); !equalInts(got, want) {
+				t.Fatalf("n %d: OverlapMatrix row %d = %v, want %v", n, i, got, want)
+			}
+
+		}
+
+	}
+
+}
+
+func BenchmarkColumnar(b *testing.B) {
+
+	rng := rand.New(rand.NewSource(31))
+	bxs := randomBoxes(rng, scanSize, 100, 20)
+	points, queries := make([][]float64, 1000), make([][2][]float64, 1000)
+
+	for i := range points {
+
+		points[i] = []float64{rng.Float64() * 110, rng.Float64() * 110}
+		queries[i] = [2][]float64{points[i], {points[i][0] + 5, points[i][1] + 5}}
+
+	}
+
+	for _, tc := range []struct {
+		name string
+		tree *BOXTree
+	}{
+		{"Tree", NewBOXTree(bxs)},
+		{"Columnar", NewBOXTree(bxs, WithColumnar())},
+	} {
+
+		b.Run("HitCounts/"+tc.name, func(b *testing.B) {
+
+			for i := 0; i < b.N; i++ {
+				tc.tree.HitCounts(points)
+			}
+
+		})
+
+		b.Run("OverlapMatrix/"+tc.name, func(b *testing.B) {
+
+			for i := 0; i < b.N; i++ {
+				tc.tree.OverlapMatrix(queries)
+			}
+
+		})
+
+	}
+
+}
+
+func TestPresorted(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(35))
+	bxs := randomBoxes(rng, 500, 100, 10)
+	bxs = append(bxs, bxs[:50]...)
+
+	if _, err := NewBOXTreeChecked(bxs, WithPresorted()); !errors.Is(err, ErrUnsorted) {
+		t.Fatalf("unsorted input: err = %v, want ErrUnsorted", err)
+	}
+
+	lowers, uppers, idxs := make([][]float64, len(bxs)), make([][]float64, len(bxs)), make([]int, len(bxs))
+
+	for i, bx := range bxs {
+
+		idxs[i] = i
+		lowers[i], uppers[i] = bx.Limits()
+
+	}
+
+	SortByAxis(lowers, uppers, idxs, 0)
+
+	for i := range bxs {
+		bxs[i] = &testBox{lowers[i], uppers[i]}
+	}
+
+	tree, err := NewBOXTreeChecked(bxs, WithPresorted(), WithDedup())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tree.Duplicates()) != 0 || tree.Len() != len(bxs) {
+		t.Fatalf("WithDedup applied under WithPresorted: %d groups, %d nodes", len(tree.Duplicates()), tree.Len())
+	}
+
+	for q := 0; q < 200; q++ {
+
+		vals := []float64{rng.Float64() * 110, rng.Float64() * 110}
+
+		if got, want := sorted(tree.Overlaps(vals)), bruteOverlaps(bxs, vals); !equalInts(got, want) {
+			t.Fatalf("Overlaps(%v) = %v, want %v", vals, got, want)
+		}
+
+	}
+
+	bxs[0], bxs[len(bxs)-1] = bxs[len(bxs)-1], bxs[0]
+
+	if err := tree.RebuildChecked(bxs); !errors.Is(err, ErrUnsorted) {
+		t.Fatalf("RebuildChecked on unsorted input: err = %v, want ErrUnsorted", err)
+	}
+
+}
+
+func TestNaNUnbounded(t *testing.T) {
+
+	nan := math.NaN()
+	bxs := []Box{
+		NewRect(nan, 0, 1, 1),
+		NewRect(0, 0, 1, nan),
+		NewRect(nan, nan, nan, nan),
+		NewRect(5, 5, 6, 6),
+	}
+
+	tree, err := NewBOXTreeChecked(bxs, WithNaNUnbounded())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		vals []float64
+		want []int
+	}{
+		{[]float64{-1e300, 0.5}, []int{0, 2}},
+		{[]float64{0.5, 1e300}, []int{1, 2}},
+		{[]float64{0.5, 0.5}, []int{0, 1, 2}},
+		{[]float64{5.5, 5.5}, []int{2, 3}},
+		{[]float64{1e300, 0.5}, []int{2}},
+	} {
+
+		if got := sorted(tree.Overlaps(tc.vals)); !equalInts(got, tc.want) {
+			t.Fatalf("Overlaps(%v) = %v, want %v", tc.vals, got, tc.want)
+		}
+
+	}
+
+	if err := tree.UpdateLimits(3, []float64{5, nan}, []float64{6, 6}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := sorted(tree.Overlaps([]float64{5.5, -1e300})); !equalInts(got, []int{2, 3}) {
+		t.Fatalf("after update: Overlaps = %v, want [2 3]", got)
+	}
+
+	if _, err := NewBOXTreeChecked([]Box{NewRect(math.Inf(-1), 0, 1, 1)}, WithNaNUnbounded()); !errors.Is(err, ErrNonFinite) {
+		t.Fatalf("-Inf limit: err = %v, want ErrNonFinite", err)
+	}
+
+	if _, err := NewBOXTreeChecked(bxs); !errors.Is(err, ErrNonFinite) {
+		t.Fatalf("NaN without WithNaNUnbounded: err = %v, want ErrNonFinite", err)
+	}
+
+}
+
+func TestAllocatorArena(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(55))
+	bxs := randomBoxes(rng, 1000, 100, 10)
+
+	flts, ints := make([]float64, 1<<14), make([]int, 1<<14)
+	fo, io, calls := 0, 0, 0
+
+	opt := WithAllocator(func(n int) []float64 {
+
+		s := flts[fo : fo+n]
+		fo, calls = fo+n, calls+1
+
+		return s
+
+	}, func(n int) []int {
+
+		s := ints[io : io+n]
+		io, calls = io+n, calls+1
+
+		return s
+
+	})
+
+	tree := NewBOXTree(bxs, opt)
+
+	if calls != 2 || io != len(bxs) || fo != 2*len(bxs) {
+		t.Fatalf("allocator: %d calls for %d ints and %d floats, want 2 for %d and %d", calls, io, fo, len(bxs), 2*len(bxs))
+	}
+
+	// both Slices are the arena's own memory
+	if &tree.idxs[0] != &ints[0] || &tree.ags[0] != &flts[0] {
+		t.Fatal("tree storage not placed in the arena")
+	}
+
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	for q := 0; q < 100; q++ {
+
+		vals := []float64{rng.Float64() * 110, rng.Float64() * 110}
+
+		if got, want := sorted(tree.Overlaps(vals)), bruteOverlaps(bxs, vals); !equalInts(got, want) {
+			t.Fatalf("Overlaps(%v) = %v, want %v", vals, got, want)
+		}
+
+	}
+
+	if raceEnabled {
+		return
+	}
+
+	// the runtime only allocates what the arena does not hold: at least the index and augmented limit Slices less than make does
+	with := testing.AllocsPerRun(10, func() {
+
+		fo, io = 0, 0
+		NewBOXTree(bxs, opt)
+
+	})
+
+	without := testing.AllocsPerRun(10, func() { NewBOXTree(bxs) })
+
+	if with > without-2 {
+		t.Fatalf("build with allocator allocates %v times, without %v; want at least 2 less", with, without)
+	}
+
+}
+
+func TestPrecomputedAreas(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(65))
+	bxs := randomBoxes(rng, 1000, 100, 10)
+	bxs = append(bxs, bxs[3])
+
+	for _, opts := range [][]Option{{}, {WithDedup()}} {
+
+		std, pre := NewBOXTree(bxs, opts...), NewBOXTree(bxs, append(opts, WithPrecomputedAreas())...)
+
+		for i, bx := range bxs {
+
+			l, u := bx.Limits()
+
+			if want := (u[0] - l[0]) * (u[1] - l[1]); std.Area(i) != want || pre.Area(i) != want {
+				t.Fatalf("Area(%d) = %v, precomputed %v, want %v", i, std.Area(i), pre.Area(i), want)
+			}
+
+		}
+
+		for q := 0; q < 300; q++ {
+
+			vals := []float64{rng.Float64() * 110, rng.Float64() * 110}
+
+			if got, want := pre.OverlapsByArea(vals), std.OverlapsByArea(vals); !equalInts(got, want) {
+				t.Fatalf("precomputed OverlapsByArea(%v) = %v, want %v", vals, got, want)
+			}
+
+		}
+
+	}
+
+	pre := NewBOXTree(bxs, WithPrecomputedAreas())
+	l, _ := bxs[5].Limits()
+
+	u := []float64{l[0] + 2, l[1] + 3}
+
+	if err := pre.UpdateLimits(5, l, u); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := pre.Area(5), (u[0]-l[0])*(u[1]-l[1]); got != want {
+		t.Fatalf("Area after UpdateLimits = %v, want %v", got, want)
+	}
+
+}
+
+func BenchmarkOverlapsByArea(b *testing.B) {
+
+	rng := rand.New(rand.NewSource(3))
+	bxs := randomBoxes(rng, 100000, 1000, 30)
+
+	qs := make([][]float64, 1024)
+
+	for i := range qs {
+		qs[i] = []float64{rng.Float64() * 1000, rng.Float64() * 1000}
+	}
+
+	for _, tc := range []struct {
+		name string
+		tree *BOXTree
+	}{
+		{"Computed", NewBOXTree(bxs)},
+		{"Precomputed", NewBOXTree(bxs, WithPrecomputedAreas())},
+	} {
+
+		b.Run(tc.name, func(b *testing.B) {
+
+			for i := 0; i < b.N; i++ {
+				tc.tree.OverlapsByArea(qs[i%len(qs)])
+			}
+
+		})
+
+	}
+
+}
@@ -0,0 +1,1265 @@
+// MIT License
+//
+// Copyright (c) 2022 geozelot (André Siefken)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package boxtree
+
+import (
+	"container/heap"
+	"context"
+	"math"
+	"math/rand"
+	gosort "sort"
+)
+
+// OverlapsWithDepth is the convenience variant of Overlaps;
+// returns the overlapping boxes together with the stacking depth at the given values, always equal to len(indices).
+//
+// Saves heatmap-style callers from a second pass for the count; results are exactly those of Overlaps.
+func (boT *BOXTree) OverlapsWithDepth(vals []float64) (indices []int, depth int) {
+
+	indices = boT.Overlaps(vals)
+
+	return indices, len(indices)
+
+}
+
+// OverlapsTouchSplit is the boundary-aware variant of Overlaps;
+// collects overlapping boxes split into those strictly containing the given values and those merely touching them.
+//
+// A box is reported in onBoundary if, on at least one axis, the value is exactly equal (==) to its lower or upper limit;
+// no epsilon is applied, so values off an edge by a rounding error count as inside (or not overlapping at all).
+// Boxes degenerated to a line or point are always on their boundary.
+func (boT *BOXTree) OverlapsTouchSplit(vals []float64) (inside, onBoundary []int) {
+
+	inside, onBoundary = []int{}, []int{}
+
+	boT.traverseAt(vals, func(cn int, at []float64) bool {
+
+		if edges(boT.lmts[2*cn], boT.lmts[2*cn+1], at) > 0 {
+			onBoundary = append(onBoundary, boT.idxs[cn])
+		} else {
+			inside = append(inside, boT.idxs[cn])
+		}
+
+		return true
+
+	})
+
+	return inside, onBoundary
+
+}
+
+// edges is an internal utility function, counting the axes on which the values lie exactly on an edge of the given limits.
+func edges(l, u, vals []float64) int {
+
+	n := 0
+
+	for ax := 0; ax < 2; ax++ {
+
+		if vals[ax] == l[ax] || vals[ax] == u[ax] {
+			n++
+		}
+
+	}
+
+	return n
+
+}
+
+// OverlapsResolve is the object variant of Overlaps;
+// traverses the tree and collects the Box returned by resolve for the index of each overlapping box.
+//
+// The resolver is called once per match, synchronously during traversal, so dense queries call it many times.
+func (boT *BOXTree) OverlapsResolve(vals []float64, resolve func(idx int) Box) []Box {
+
+	res := []Box{}
+
+	boT.traverse(vals, func(cn int) bool {
+
+		res = append(res, resolve(boT.idxs[cn]))
+		return true
+
+	})
+
+	return res
+
+}
+
+// OverlapsTile is the tile variant of the box-vs-box search;
+// collects boxes intersecting the tile given by its bounds as {{minX, minY}, {maxX, maxY}}.
+func (boT *BOXTree) OverlapsTile(tileBounds [2][2]float64) []int {
+
+	res := []int{}
+
+	boT.traverseBox(tileBounds[0][:], tileBounds[1][:], func(cn int) bool {
+
+		res = append(res, boT.idxs[cn])
+		return true
+
+	})
+
+	return res
+
+}
+
+// OverlapsTileXYZ is the tile coordinate variant of OverlapsTile;
+// maps the tile z/x/y to its bounds via the given projection function and collects the boxes intersecting it.
+//
+// The projection is left to the caller so that tiling schemes other than Web Mercator work alike.
+func (boT *BOXTree) OverlapsTileXYZ(z, x, y int, project func(z, x, y int) [2][2]float64) []int {
+
+	return boT.OverlapsTile(project(z, x, y))
+
+}
+
+// OverlapsChan is the channel variant of Overlaps;
+// traverses the tree in a separate goroutine, sending the index of each overlapping box as soon as it is found.
+//
+// The channel is closed once the traversal completes or ctx is cancelled. Callers must either drain it or cancel ctx,
+// otherwise the goroutine leaks blocked on its next send.
+func (boT *BOXTree) OverlapsChan(ctx context.Context, vals []float64) <-chan int {
+
+	ch := make(chan int)
+
+	go func() {
+
+		defer close(ch)
+
+		boT.traverse(vals, func(cn int) bool {
+
+			select {
+			case ch <- boT.idxs[cn]:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+
+		})
+
+	}()
+
+	return ch
+
+}
+
+// OverlapsQuadrants is the quadrant partitioned variant of Overlaps;
+// collects overlapping boxes split by the quadrant their center lies in relative to the given values, in order NE, NW, SE, SW.
+//
+// Centers exactly on an axis through the values count as east (x) or north (y) respectively, so a box centered on the values is NE.
+func (boT *BOXTree) OverlapsQuadrants(vals []float64) [4][]int {
+
+	res := [4][]int{{}, {}, {}, {}}
+
+	boT.traverseAt(vals, func(cn int, at []float64) bool {
+
+		l, u := boT.lmts[2*cn], boT.lmts[2*cn+1]
+		q := 0
+
+		if (l[0]+u[0])/2.0 < at[0] {
+			q++
+		}
+
+		if (l[1]+u[1])/2.0 < at[1] {
+			q += 2
+		}
+
+		res[q] = append(res[q], boT.idxs[cn])
+		return true
+
+	})
+
+	return res
+
+}
+
+// OverlapsOrdered is the deterministic variant of Overlaps;
+// collects overlapping boxes like Overlaps, sorted by ascending original index.
+//
+// This is the canonical output for comparisons across runs and builds, as the randomized build
+// makes the plain Overlaps order vary; the sort costs O(k log k) for k results.
+func (boT *BOXTree) OverlapsOrdered(vals []float64) []int {
+
+	res := boT.Overlaps(vals)
+	gosort.Ints(res)
+
+	return res
+
+}
+
+// HitKind is the classification of a point hit by OverlapsHitKind.
+type HitKind int
+
+// HitKind values, by the number of axes on which the point lies exactly on an edge of the box.
+const (
+	Interior HitKind = iota
+	Edge
+	Corner
+)
+
+// HitResult is a single match of OverlapsHitKind; holds the original box index and how the point hit it.
+type HitResult struct {
+	Index int
+	Kind  HitKind
+}
+
+// OverlapsHitKind is the hit classifying variant of Overlaps;
+// collects overlapping boxes along with whether the values lie strictly inside, exactly on an edge or exactly on a corner.
+//
+// Like OverlapsTouchSplit, edges are detected by exact equality (==) without any epsilon.
+func (boT *BOXTree) OverlapsHitKind(vals []float64) []HitResult {
+
+	res := []HitResult{}
+
+	boT.traverseAt(vals, func(cn int, at []float64) bool {
+
+		res = append(res, HitResult{boT.idxs[cn], HitKind(edges(boT.lmts[2*cn], boT.lmts[2*cn+1], at))})
+		return true
+
+	})
+
+	return res
+
+}
+
+// OverlapsWithMargin is the tolerant variant of Overlaps;
+// collects overlapping boxes as hits and boxes within the given Euclidean distance of the values, but not overlapping, as near misses.
+//
+// Subtrees are pruned by the augmented limits expanded by margin; a box exactly margin away counts as near.
+func (boT *BOXTree) OverlapsWithMargin(vals []float64, margin float64) (hits, near []int) {
+
+	hits, near = []int{}, []int{}
+
+	lower := []float64{vals[0] - margin, vals[1] - margin}
+	upper := []float64{vals[0] + margin, vals[1] + margin}
+
+	boT.traverseBox(lower, upper, func(cn int) bool {
+
+		if d := distance(boT.lmts[2*cn], boT.lmts[2*cn+1], vals); d == 0 {
+			hits = append(hits, boT.idxs[cn])
+		} else if d <= margin {
+			near = append(near, boT.idxs[cn])
+		}
+
+		return true
+
+	})
+
+	return hits, near
+
+}
+
+// OverlapSummary is the result of OverlapsSummary;
+// holds the matched original indices, their number and the combined bounding box of all matches as {lower, upper}.
+type OverlapSummary struct {
+	Indices     []int
+	Count       int
+	MatchBounds [2][]float64
+}
+
+// OverlapsSummary is the aggregating variant of Overlaps;
+// collects overlapping boxes along with their count and combined bounding box in a single traversal.
+//
+// Without matches, Indices is empty and both MatchBounds are nil.
+func (boT *BOXTree) OverlapsSummary(vals []float64) OverlapSummary {
+
+	sum := OverlapSummary{Indices: []int{}}
+
+	boT.traverse(vals, func(cn int) bool {
+
+		l, u := boT.lmts[2*cn], boT.lmts[2*cn+1]
+
+		if sum.Count == 0 {
+			sum.MatchBounds = [2][]float64{{l[0], l[1]}, {u[0], u[1]}}
+		}
+
+		for ax := 0; ax < 2; ax++ {
+
+			sum.MatchBounds[0][ax] = math.Min(sum.MatchBounds[0][ax], l[ax])
+			sum.MatchBounds[1][ax] = math.Max(sum.MatchBounds[1][ax], u[ax])
+
+		}
+
+		sum.Indices = append(sum.Indices, boT.idxs[cn])
+		sum.Count++
+
+		return true
+
+	})
+
+	return sum
+
+}
+
+// OverlapMatrix is the batch variant of the box-vs-box search;
+// returns, per query box given as {lower, upper}, the indices of all stored boxes intersecting it.
+//
+// All queries share one scratch buffer, so each row costs a single allocation.
+func (boT *BOXTree) OverlapMatrix(queries [][2][]float64) [][]int {
+
+	res := make([][]int, len(queries))
+
+	buf := buffers.Get().(*buffer)
+	row := buf.res[:0]
+
+	for i, q := range queries {
+
+		row = row[:0]
+
+		if boT.columnar && len(boT.idxs) <= scanSize {
+
+			boT.scanBox(q[0], q[1], func(cn int) {
+				row = append(row, boT.idxs[cn])
+			})
+
+		} else {
+
+			boT.walkBox(buf, q[0], q[1], intersects, func(cn int) bool {
+
+				row = append(row, boT.idxs[cn])
+				return true
+
+			})
+
+		}
+
+		res[i] = make([]int, len(row))
+		copy(res[i], row)
+
+	}
+
+	buf.res = row[:0]
+	buffers.Put(buf)
+
+	return res
+
+}
+
+// OverlapsBoxBounds is the boundary configurable variant of the box-vs-box search;
+// collects stored boxes intersecting the box given by its limits, with each query bound per axis either closed (inclusive) or open.
+//
+// An open lower bound excludes stored boxes ending exactly on it, an open upper bound those starting exactly on it;
+// stored boxes themselves are always closed.
+func (boT *BOXTree) OverlapsBoxBounds(lower, upper []float64, lowerClosed, upperClosed []bool) []int {
+
+	res := []int{}
+
+	hit := func(l, u, lower, upper []float64) bool {
+
+		for ax := 0; ax < 2; ax++ {
+
+			if u[ax] < lower[ax] || !lowerClosed[ax] && u[ax] == lower[ax] {
+				return false
+			}
+
+			if l[ax] > upper[ax] || !upperClosed[ax] && l[ax] == upper[ax] {
+				return false
+			}
+
+		}
+
+		return true
+
+	}
+
+	buf := buffers.Get().(*buffer)
+
+	boT.walkBox(buf, lower, upper, hit, func(cn int) bool {
+
+		res = append(res, boT.idxs[cn])
+		return true
+
+	})
+
+	buffers.Put(buf)
+
+	return res
+
+}
+
+// TransformedMatch is a single match of OverlapsTransformed; holds the original box index and its transformed limits.
+type TransformedMatch struct {
+	Index        int
+	Lower, Upper []float64
+}
+
+// OverlapsTransformed is the reprojecting variant of Overlaps;
+// collects overlapping boxes along with their limits mapped through tf, e.g. into a presentation coordinate system.
+//
+// The values are matched in the tree's native coordinates. tf receives the stored limits, which it must not modify.
+func (boT *BOXTree) OverlapsTransformed(vals []float64, tf func(lower, upper []float64) (outLower, outUpper []float64)) []TransformedMatch {
+
+	res := []TransformedMatch{}
+
+	boT.traverse(vals, func(cn int) bool {
+
+		l, u := tf(boT.lmts[2*cn], boT.lmts[2*cn+1])
+		res = append(res, TransformedMatch{boT.idxs[cn], l, u})
+
+		return true
+
+	})
+
+	return res
+
+}
+
+// OverlapsAreaBudget is the budget limited variant of Overlaps;
+// collects overlapping boxes until their summed area reaches maxArea, then stops the traversal.
+//
+// Results are a prefix in traversal order, not sorted by area; the box reaching the budget is included,
+// so the summed area may exceed maxArea by at most one box. A maxArea of zero or below returns no boxes.
+func (boT *BOXTree) OverlapsAreaBudget(vals []float64, maxArea float64) []int {
+
+	res := []int{}
+
+	if maxArea <= 0 {
+		return res
+	}
+
+	sum := 0.0
+
+	boT.traverse(vals, func(cn int) bool {
+
+		res = append(res, boT.idxs[cn])
+		sum += boT.area(cn)
+
+		return sum < maxArea
+
+	})
+
+	return res
+
+}
+
+// OverlapsSampled is the sampling variant of Overlaps for previews;
+// includes each overlapping box independently with probability rate, drawing from the package level source of math/rand.
+//
+// Sampling is statistical, not a fixed fraction: on average rate times the full result count is returned, varying from call to call;
+// use OverlapsSampledRand for reproducible samples. The traversal is the same as for Overlaps, so only result handling is saved;
+// a rate of 1 or above returns all overlapping boxes.
+func (boT *BOXTree) OverlapsSampled(vals []float64, rate float64) []int {
+
+	return boT.sample(vals, rate, rand.Float64)
+
+}
+
+// OverlapsSampledRand is the seeded variant of OverlapsSampled; draws from the given source, which is not safe for concurrent use,
+// so equally seeded sources yield equal samples on the same tree.
+func (boT *BOXTree) OverlapsSampledRand(vals []float64, rate float64, rnd *rand.Rand) []int {
+
+	return boT.sample(vals, rate, rnd.Float64)
+
+}
+
+// sample is the internal sampling function behind OverlapsSampled and OverlapsSampledRand.
+func (boT *BOXTree) sample(vals []float64, rate float64, draw func() float64) []int {
+
+	res := []int{}
+
+	if rate <= 0 {
+		return res
+	}
+
+	boT.traverse(vals, func(cn int) bool {
+
+		if rate >= 1 || draw() < rate {
+			res = append(res, boT.idxs[cn])
+		}
+
+		return true
+
+	})
+
+	return res
+
+}
+
+// OverlapsByArea is the layering variant of Overlaps;
+// collects overlapping boxes sorted by ascending area of their stored limits, so the most specific box comes first.
+//
+// Boxes of equal area are ordered by ascending original index; the sort costs O(k log k) for k results.
+func (boT *BOXTree) OverlapsByArea(vals []float64) []int {
+
+	res, ars := []int{}, []float64{}
+
+	boT.traverse(vals, func(cn int) bool {
+
+		res = append(res, boT.idxs[cn])
+		ars = append(ars, boT.area(cn))
+
+		return true
+
+	})
+
+	gosort.Sort(byArea{res, ars})
+
+	return res
+
+}
+
+// byArea is the internal sort.Interface ordering result indices by area, then index.
+type byArea struct {
+	idxs []int
+	ars  []float64
+}
+
+func (ba byArea) Len() int {
+	return len(ba.idxs)
+}
+
+func (ba byArea) Less(i, j int) bool {
+
+	if ba.ars[i] != ba.ars[j] {
+		return ba.ars[i] < ba.ars[j]
+	}
+
+	return ba.idxs[i] < ba.idxs[j]
+
+}
+
+func (ba byArea) Swap(i, j int) {
+
+	ba.idxs[i], ba.idxs[j] = ba.idxs[j], ba.idxs[i]
+	ba.ars[i], ba.ars[j] = ba.ars[j], ba.ars[i]
+
+}
+
+// OverlapsDeepest is the structural specificity variant of Overlaps;
+// collects overlapping boxes like Overlaps, plus the index of the one found at the greatest tree depth (-1 if none),
+// the first one encountered on equal depth.
+//
+// Depth is a structural notion of the tree layout, not geometric containment: the deepest box need not lie within any other match.
+// For the geometrically most specific match, use the first index of OverlapsByArea.
+func (boT *BOXTree) OverlapsDeepest(vals []float64) (all []int, deepest int) {
+
+	all, deepest = []int{}, -1
+	dd := -1
+
+	boT.traverse(vals, func(cn int) bool {
+
+		all = append(all, boT.idxs[cn])
+
+		if d := boT.level(cn); d > dd {
+			deepest, dd = boT.idxs[cn], d
+		}
+
+		return true
+
+	})
+
+	return all, deepest
+
+}
+
+// level is the internal node depth function; returns the depth of the node at position p, with the root at 0.
+//
+// Nodes within a leaf bucket (see WithLeafSize) share the depth of the bucket.
+func (boT *BOXTree) level(p int) int {
+
+	lb, rb := 0, len(boT.idxs)-1
+
+	for d := 0; ; d++ {
+
+		cn := int(math.Ceil(float64(lb+rb) / 2.0))
+
+		if cn == p || rb-lb < boT.leaf {
+			return d
+		}
+
+		if p < cn {
+			rb = cn - 1
+		} else {
+			lb = cn + 1
+		}
+
+	}
+
+}
+
+// OverlapsHash is the fingerprint variant of Overlaps;
+// returns an order independent 64 bit hash of the overlapping box indices, 0 if there are none.
+//
+// The hash is the XOR of a mix of each index, so it is stable across traversal orders and rebuilds from the same boxes;
+// equal hashes suggest, but do not prove, equal result sets.
+func (boT *BOXTree) OverlapsHash(vals []float64) uint64 {
+
+	var h uint64
+
+	boT.traverse(vals, func(cn int) bool {
+
+		h ^= mix(uint64(boT.idxs[cn]))
+		return true
+
+	})
+
+	return h
+
+}
+
+// mix is an internal utility function, scrambling a 64 bit value (SplitMix64 finalizer).
+func mix(x uint64) uint64 {
+
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+
+	return x ^ (x >> 31)
+
+}
+
+// OverlapsDFS is the locality preserving variant of Overlaps;
+// collects overlapping boxes in strict depth-first, left-to-right (in-order) sequence of the tree, i.e. by ascending node position.
+//
+// Along the root axis consecutive results are thus close in lower limit, and the order is deterministic for a given build.
+// The order of plain Overlaps is unspecified and follows its internal stack instead; both return the same boxes.
+// The matches are collected like Overlaps does and sorted by node position afterwards, in O(k log k) for k matches.
+func (boT *BOXTree) OverlapsDFS(vals []float64) []int {
+
+	cns := []int{}
+
+	boT.traverse(vals, func(cn int) bool {
+
+		cns = append(cns, cn)
+		return true
+
+	})
+
+	gosort.Ints(cns)
+
+	for i, cn := range cns {
+		cns[i] = boT.idxs[cn]
+	}
+
+	return cns
+
+}
+
+// OverlapsLocal is the memory local variant of Overlaps;
+// collects overlapping boxes by ascending node position, so consecutive results are adjacent in the tree's storage.
+//
+// Node position order is the in-order sequence of the tree, so this is an alias of OverlapsDFS; see Positions for the mapping
+// from original index to node position. Whether the order pays off depends on the caller: with the sort included,
+// a loop fetching stored limits by position was not measurably faster than over plain Overlaps (see BenchmarkLimitsFetch).
+func (boT *BOXTree) OverlapsLocal(vals []float64) []int {
+
+	return boT.OverlapsDFS(vals)
+
+}
+
+// OverlapsInRegion is the region restricted variant of Overlaps;
+// collects overlapping boxes that also intersect the region given by its lower and upper limits, boundary included.
+//
+// Both constraints combine into a single box query: a box contains the values and intersects the region exactly if it intersects
+// the box from max(vals, regionLower) to min(vals, regionUpper) per axis (possibly inverted), so the region prunes subtrees as well.
+// Values outside the region still match boxes reaching into it. For boxes lying entirely within the region, use OverlapsInRegionContained.
+func (boT *BOXTree) OverlapsInRegion(vals []float64, regionLower, regionUpper []float64) []int {
+
+	res := []int{}
+
+	lower := []float64{math.Max(vals[0], regionLower[0]), math.Max(vals[1], regionLower[1])}
+	upper := []float64{math.Min(vals[0], regionUpper[0]), math.Min(vals[1], regionUpper[1])}
+
+	boT.traverseBox(lower, upper, func(cn int) bool {
+
+		res = append(res, boT.idxs[cn])
+		return true
+
+	})
+
+	return res
+
+}
+
+// OverlapsInRegionContained is the containment variant of OverlapsInRegion;
+// collects overlapping boxes that lie entirely within the region given by its lower and upper limits, boundary included.
+//
+// Every such box contains the values, so values outside the region return without any traversal; otherwise pruning is that of Overlaps,
+// as the augmented limits bound boxes from outside only and cannot exclude boxes reaching past the region.
+func (boT *BOXTree) OverlapsInRegionContained(vals []float64, regionLower, regionUpper []float64) []int {
+
+	res := []int{}
+
+	if !within(regionLower, regionUpper, vals) {
+		return res
+	}
+
+	boT.traverse(vals, func(cn int) bool {
+
+		if within(regionLower, regionUpper, boT.lmts[2*cn]) && within(regionLower, regionUpper, boT.lmts[2*cn+1]) {
+			res = append(res, boT.idxs[cn])
+		}
+
+		return true
+
+	})
+
+	return res
+
+}
+
+// OverlapsScaled is the input transforming variant of Overlaps;
+// maps the given values into the tree's coordinates as vals[ax]*scale[ax]+offset[ax] per axis, then collects the overlapping boxes.
+//
+// The values themselves are left unchanged; the output side counterpart is OverlapsTransformed.
+func (boT *BOXTree) OverlapsScaled(vals []float64, scale, offset []float64) []int {
+
+	return boT.Overlaps([]float64{vals[0]*scale[0] + offset[0], vals[1]*scale[1] + offset[1]})
+
+}
+
+// OverlapsFuncLimits is the streaming variant of Overlaps with geometry;
+// calls fn with the index and the stored limits of each overlapping box, stopping the traversal as soon as fn returns false.
+//
+// The limit Slices are those held by the tree; treat them as read-only and valid only during the call of fn.
+func (boT *BOXTree) OverlapsFuncLimits(vals []float64, fn func(idx int, lower, upper []float64) bool) {
+
+	boT.traverse(vals, func(cn int) bool {
+
+		return fn(boT.idxs[cn], boT.lmts[2*cn], boT.lmts[2*cn+1])
+
+	})
+
+}
+
+// AreaMatch is a single result of TopKByOverlapArea.
+type AreaMatch struct {
+	Index int
+	Area  float64
+}
+
+// TopKByOverlapArea is the ranked box query;
+// returns the k boxes with the largest area of intersection with the query box given by its lower and upper limits, by descending area.
+//
+// Boxes merely touching the query box rank with an area of 0; equal areas are ordered by ascending index.
+// Candidates are kept in a heap of size k during the traversal, so only O(k) results are held at any time.
+func (boT *BOXTree) TopKByOverlapArea(lower, upper []float64, k int) []AreaMatch {
+
+	if k <= 0 {
+		return []AreaMatch{}
+	}
+
+	hp := areaHeap{}
+
+	boT.traverseBox(lower, upper, func(cn int) bool {
+
+		l, u := boT.lmts[2*cn], boT.lmts[2*cn+1]
+		m := AreaMatch{boT.idxs[cn], (math.Min(u[0], upper[0]) - math.Max(l[0], lower[0])) * (math.Min(u[1], upper[1]) - math.Max(l[1], lower[1]))}
+
+		if len(hp) < k {
+			heap.Push(&hp, m)
+		} else if hp.worse(hp[0], m) {
+
+			hp[0] = m
+			heap.Fix(&hp, 0)
+
+		}
+
+		return true
+
+	})
+
+	res := make([]AreaMatch, len(hp))
+
+	for i := len(res) - 1; i >= 0; i-- {
+		res[i] = heap.Pop(&hp).(AreaMatch)
+	}
+
+	return res
+
+}
+
+// areaHeap is the internal heap.Interface of TopKByOverlapArea, holding the worst of the current top matches on top.
+type areaHeap []AreaMatch
+
+func (ah areaHeap) worse(a, b AreaMatch) bool {
+
+	if a.Area != b.Area {
+		return a.Area < b.Area
+	}
+
+	return a.Index > b.Index
+
+}
+
+func (ah areaHeap) Len() int {
+	return len(ah)
+}
+
+func (ah areaHeap) Less(i, j int) bool {
+	return ah.worse(ah[i], ah[j])
+}
+
+func (ah areaHeap) Swap(i, j int) {
+	ah[i], ah[j] = ah[j], ah[i]
+}
+
+func (ah *areaHeap) Push(x interface{}) {
+	*ah = append(*ah, x.(AreaMatch))
+}
+
+func (ah *areaHeap) Pop() interface{} {
+
+	old := *ah
+	x := old[len(old)-1]
+	*ah = old[:len(old)-1]
+
+	return x
+
+}
+
+// OverlapsMBR is the aggregating variant of Overlaps;
+// returns the minimum bounding rectangle of all overlapping boxes and their number, without collecting their indices.
+//
+// Returns nil limits and 0 if no box overlaps the values; the returned limits are new Slices.
+func (boT *BOXTree) OverlapsMBR(vals []float64) (lower, upper []float64, n int) {
+
+	boT.traverse(vals, func(cn int) bool {
+
+		l, u := boT.lmts[2*cn], boT.lmts[2*cn+1]
+
+		if n == 0 {
+			lower, upper = []float64{l[0], l[1]}, []float64{u[0], u[1]}
+		}
+
+		for ax := 0; ax < 2; ax++ {
+
+			lower[ax] = math.Min(lower[ax], l[ax])
+			upper[ax] = math.Max(upper[ax], u[ax])
+
+		}
+
+		n++
+
+		return true
+
+	})
+
+	return lower, upper, n
+
+}
+
+// OverlapsUntil is the generic early exit variant of Overlaps;
+// calls step with the index of each overlapping box, stopping the traversal as soon as step returns true.
+//
+// step keeps its own state, e.g. a counter, a summed area or a first match. The traversal order is unspecified,
+// so stop conditions should not depend on it; see OverlapsDFS for a defined order. No further nodes are visited after the stop.
+func (boT *BOXTree) OverlapsUntil(vals []float64, step func(idx int) (stop bool)) {
+
+	boT.traverse(vals, func(cn int) bool {
+
+		return !step(boT.idxs[cn])
+
+	})
+
+}
+
+// EdgeMatch is a single result of OverlapsEdgeDistance.
+type EdgeMatch struct {
+	Index    int
+	EdgeDist float64
+}
+
+// OverlapsEdgeDistance is the depth annotated variant of Overlaps;
+// collects overlapping boxes along with the distance from the values to the nearest edge of each box, 0 on an edge.
+func (boT *BOXTree) OverlapsEdgeDistance(vals []float64) []EdgeMatch {
+
+	res := []EdgeMatch{}
+
+	boT.traverseAt(vals, func(cn int, at []float64) bool {
+
+		l, u := boT.lmts[2*cn], boT.lmts[2*cn+1]
+		d := math.Min(math.Min(at[0]-l[0], u[0]-at[0]), math.Min(at[1]-l[1], u[1]-at[1]))
+
+		res = append(res, EdgeMatch{boT.idxs[cn], d})
+		return true
+
+	})
+
+	return res
+
+}
+
+// OverlapsBucketed is the integer grouped variant of Overlaps;
+// collects overlapping boxes into nBuckets Slices, placing each at the position bucket returns for its index.
+//
+// Boxes for which bucket returns a value outside [0, nBuckets) are ignored; all Slices are non-nil.
+func (boT *BOXTree) OverlapsBucketed(vals []float64, bucket func(idx int) int, nBuckets int) [][]int {
+
+	if nBuckets < 0 {
+		nBuckets = 0
+	}
+
+	res := make([][]int, nBuckets)
+
+	for b := range res {
+		res[b] = []int{}
+	}
+
+	boT.traverse(vals, func(cn int) bool {
+
+		if b := bucket(boT.idxs[cn]); b >= 0 && b < nBuckets {
+			res[b] = append(res[b], boT.idxs[cn])
+		}
+
+		return true
+
+	})
+
+	return res
+
+}
+
+// OverlapsCentroid is the weighted center variant of Overlaps;
+// returns the area weighted average of the centers of all overlapping boxes and their number.
+//
+// Returns nil and 0 if no box overlaps the values. If all matches have zero area (points or segments), their centers are averaged unweighted.
+func (boT *BOXTree) OverlapsCentroid(vals []float64) (centroid []float64, n int) {
+
+	var sum, avg [2]float64
+	wt := 0.0
+
+	boT.traverse(vals, func(cn int) bool {
+
+		l, u := boT.lmts[2*cn], boT.lmts[2*cn+1]
+		a := boT.area(cn)
+
+		for ax := 0; ax < 2; ax++ {
+
+			c := (l[ax] + u[ax]) / 2.0
+
+			sum[ax] += a * c
+			avg[ax] += c
+
+		}
+
+		wt += a
+		n++
+
+		return true
+
+	})
+
+	if n == 0 {
+		return nil, 0
+	}
+
+	if wt == 0 {
+		return []float64{avg[0] / float64(n), avg[1] / float64(n)}, n
+	}
+
+	return []float64{sum[0] / wt, sum[1] / wt}, n
+
+}
+
+// OverlapsAxis is the single axis slab query;
+// collects all boxes whose limits on axis ax contain v, i.e. lower[ax] <= v <= upper[ax], regardless of the other axis.
+//
+// Only levels split on ax can prune, so roughly every other level is descended unconditionally; still cheaper than a full scan.
+func (boT *BOXTree) OverlapsAxis(ax int, v float64) []int {
+
+	res := []int{}
+
+	lower, upper := []float64{math.Inf(-1), math.Inf(-1)}, []float64{math.Inf(1), math.Inf(1)}
+	lower[ax], upper[ax] = v, v
+
+	boT.traverseBox(lower, upper, func(cn int) bool {
+
+		res = append(res, boT.idxs[cn])
+		return true
+
+	})
+
+	return res
+
+}
+
+// OverlapsEncode is the serializing variant of Overlaps;
+// calls enc with the index and the stored limits of each overlapping box, stopping the traversal at, and returning, the first error.
+//
+// The limit Slices are those held by the tree; enc must treat them as read-only and copy, not retain, them.
+func (boT *BOXTree) OverlapsEncode(vals []float64, enc func(idx int, lower, upper []float64) error) error {
+
+	var err error
+
+	boT.traverse(vals, func(cn int) bool {
+
+		err = enc(boT.idxs[cn], boT.lmts[2*cn], boT.lmts[2*cn+1])
+		return err == nil
+
+	})
+
+	return err
+
+}
+
+// OverlapsOnePerCell is the thinning variant of Overlaps;
+// collects at most one overlapping box per cell of a square grid with the given cell size, anchored at the origin.
+//
+// Boxes are assigned to the cell containing their center, and the first box encountered in traversal order is kept per cell;
+// as that order is unspecified, which box represents a cell may vary between builds. Panics if cellSize is not positive.
+func (boT *BOXTree) OverlapsOnePerCell(vals []float64, cellSize float64) []int {
+
+	if !(cellSize > 0) {
+		panic("boxtree: cell size must be positive")
+	}
+
+	res := []int{}
+	seen := map[[2]int64]bool{}
+
+	boT.traverse(vals, func(cn int) bool {
+
+		l, u := boT.lmts[2*cn], boT.lmts[2*cn+1]
+		k := [2]int64{int64(math.Floor((l[0] + u[0]) / 2.0 / cellSize)), int64(math.Floor((l[1] + u[1]) / 2.0 / cellSize))}
+
+		if !seen[k] {
+
+			seen[k] = true
+			res = append(res, boT.idxs[cn])
+
+		}
+
+		return true
+
+	})
+
+	return res
+
+}
+
+// OverlapsMinimal is the most specific variant of Overlaps;
+// collects the overlapping boxes that do not fully contain any other overlapping box.
+//
+// Containment includes shared edges, but identical boxes do not exclude each other, so all copies of an innermost box are kept.
+// Uses a pairwise containment pass over the k matches, costing O(k²).
+func (boT *BOXTree) OverlapsMinimal(vals []float64) []int {
+
+	cns := []int{}
+
+	boT.traverse(vals, func(cn int) bool {
+
+		cns = append(cns, cn)
+		return true
+
+	})
+
+	res := []int{}
+
+	for _, a := range cns {
+
+		al, au := boT.lmts[2*a], boT.lmts[2*a+1]
+		min := true
+
+		for _, b := range cns {
+
+			bl, bu := boT.lmts[2*b], boT.lmts[2*b+1]
+
+			if within(al, au, bl) && within(al, au, bu) && !(within(bl, bu, al) && within(bl, bu, au)) {
+				min = false
+				break
+			}
+
+		}
+
+		if min {
+			res = append(res, boT.idxs[a])
+		}
+
+	}
+
+	return res
+
+}
+
+// OverlapsSpread is the capped, spatially diverse variant of Overlaps;
+// returns at most max overlapping boxes, spread over the area the matches cover instead of clustered.
+//
+// The bounding rectangle of all match centers is cut into a grid of about max cells (⌈√max⌉ per axis), each match assigned to the cell of its center;
+// results are taken round-robin, one per non-empty cell per round, in traversal order within a cell, until max is reached.
+// All matches are collected first, so the traversal is not cut short.
+func (boT *BOXTree) OverlapsSpread(vals []float64, max int) []int {
+
+	res := []int{}
+
+	if max <= 0 {
+		return res
+	}
+
+	cns := []int{}
+	lo, hi := []float64{math.Inf(1), math.Inf(1)}, []float64{math.Inf(-1), math.Inf(-1)}
+
+	boT.traverse(vals, func(cn int) bool {
+
+		l, u := boT.lmts[2*cn], boT.lmts[2*cn+1]
+
+		for ax := 0; ax < 2; ax++ {
+
+			lo[ax] = math.Min(lo[ax], (l[ax]+u[ax])/2.0)
+			hi[ax] = math.Max(hi[ax], (l[ax]+u[ax])/2.0)
+
+		}
+
+		cns = append(cns, cn)
+		return true
+
+	})
+
+	if len(cns) <= max {
+
+		for _, cn := range cns {
+			res = append(res, boT.idxs[cn])
+		}
+
+		return res
+
+	}
+
+	g := int(math.Ceil(math.Sqrt(float64(max))))
+	cls := make([][]int, g*g)
+
+	for _, cn := range cns {
+
+		l, u := boT.lmts[2*cn], boT.lmts[2*cn+1]
+		c := 0
+
+		for ax, f := range [2]int{1, g} {
+
+			if hi[ax] > lo[ax] {
+
+				if i := int(float64(g) * ((l[ax]+u[ax])/2.0 - lo[ax]) / (hi[ax] - lo[ax])); i < g {
+					c += f * i
+				} else {
+					c += f * (g - 1)
+				}
+
+			}
+
+		}
+
+		cls[c] = append(cls[c], cn)
+
+	}
+
+	for r := 0; len(res) < max; r++ {
+
+		for _, cl := range cls {
+
+			if r < len(cl) && len(res) < max {
+				res = append(res, boT.idxs[cl[r]])
+			}
+
+		}
+
+	}
+
+	return res
+
+}
+
+// SemanticMode is the overlap semantics selector of OverlapsMode.
+type SemanticMode int
+
+// Overlap semantics of OverlapsMode, per axis, for a box with limits l and u and a value v:
+// ModeInclusive matches l <= v <= u (as Overlaps), ModeStrict matches l < v < u (interior only, see also OverlapsTouchSplit)
+// and ModeHalfOpen matches l <= v < u, so boxes tiling the plane never share a match.
+//
+// Tolerance and unbounded semantics have parameters or apply to the build: see OverlapsWithMargin and WithNaNUnbounded.
+const (
+	ModeInclusive SemanticMode = iota
+	ModeStrict
+	ModeHalfOpen
+)
+
+// OverlapsMode is the semantics dispatching variant of Overlaps;
+// collects the boxes overlapping the given values under the given mode, ModeInclusive reproducing Overlaps exactly.
+//
+// Unknown modes match nothing.
+func (boT *BOXTree) OverlapsMode(vals []float64, mode SemanticMode) []int {
+
+	if mode == ModeInclusive {
+		return boT.Overlaps(vals)
+	}
+
+	if hit := predicate(mode); hit != nil {
+		return boT.OverlapsCustom(vals, hit)
+	}
+
+	return []int{}
+
+}
+
+// predicate is the internal SemanticMode lookup function; returns the hit predicate of the given mode, or nil for unknown modes.
+func predicate(mode SemanticMode) func(l, u, vals []float64) bool {
+
+	switch mode {
+
+	case ModeInclusive:
+		return within
+
+	case ModeStrict:
+		return func(l, u, vals []float64) bool {
+			return l[0] < vals[0] && vals[0] < u[0] && l[1] < vals[1] && vals[1] < u[1]
+		}
+
+	case ModeHalfOpen:
+		return func(l, u, vals []float64) bool {
+			return l[0] <= vals[0] && vals[0] < u[0] && l[1] <= vals[1] && vals[1] < u[1]
+		}
+
+	}
+
+	return nil
+
+}
+
+// Do is the configurable query entry point;
+// collects the boxes overlapping q.Vals under q.Mode into q.Dst, applying q.Filter and q.Limit, and returns the result.
+//
+// The result shares the backing array of q.Dst, which is updated to it, so reusing q for further calls reuses its buffers
+// and overwrites previous results. Trees built by NewBOXTreeWrapped are queried with wrapping, like Overlaps; unknown modes match nothing.
+func (boT *BOXTree) Do(q *Query) []int {
+
+	res := q.Dst[:0]
+	hit := predicate(q.Mode)
+
+	if hit != nil {
+
+		boT.walk(&q.buf, q.Vals, hit, func(cn int) bool {
+
+			if q.Filter == nil || q.Filter(boT.idxs[cn]) {
+				res = append(res, boT.idxs[cn])
+			}
+
+			return q.Limit <= 0 || len(res) < q.Limit
+
+		})
+
+	}
+
+	if res == nil {
+		res = []int{}
+	}
+
+	q.Dst = res
+
+	return res
+
+}
@@ -0,0 +1,1452 @@
+package boxtree
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/rand"
+	"runtime"
+	gosort "sort"
+	"testing"
+	"time"
+)
+
+func TestOverlapsDFSOrder(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(10))
+
+	for _, lf := range []int{0, 8} {
+
+		bxs := randomBoxes(rng, 2000, 100, 15)
+		tree := NewBOXTree(bxs, WithLeafSize(lf))
+		pos := tree.Positions()
+
+		for q := 0; q < 300; q++ {
+
+			vals := []float64{rng.Float64() * 110, rng.Float64() * 110}
+			res := tree.OverlapsDFS(vals)
+
+			for i := 1; i < len(res); i++ {
+
+				if pos[res[i-1]] >= pos[res[i]] {
+					t.Fatalf("leaf %d, OverlapsDFS(%v) = %v not ascending by node position", lf, vals, res)
+				}
+
+			}
+
+			if got, want := sorted(res), bruteOverlaps(bxs, vals); !equalInts(got, want) {
+				t.Fatalf("leaf %d, OverlapsDFS(%v) = %v, want %v", lf, vals, got, want)
+			}
+
+		}
+
+	}
+
+}
+
+func TestOverlapsLocalOrder(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(40))
+	bxs := randomBoxes(rng, 3000, 100, 20)
+	tree := NewBOXTree(bxs)
+	pos := tree.Positions()
+
+	for q := 0; q < 100; q++ {
+
+		vals := []float64{rng.Float64() * 110, rng.Float64() * 110}
+		res := tree.OverlapsLocal(vals)
+
+		for i := 1; i < len(res); i++ {
+
+			if pos[res[i-1]] >= pos[res[i]] {
+				t.Fatalf("OverlapsLocal(%v): positions %d, %d not ascending", vals, pos[res[i-1]], pos[res[i]])
+			}
+
+		}
+
+		if got, want := sorted(res), bruteOverlaps(bxs, vals); !equalInts(got, want) {
+			t.Fatalf("OverlapsLocal(%v) = %v, want %v", vals, got, want)
+		}
+
+	}
+
+}
+
+func BenchmarkLimitsFetch(b *testing.B) {
+
+	rng := rand.New(rand.NewSource(11))
+	tree := NewBOXTree(randomBoxes(rng, 200000, 1000, 60))
+	pos := tree.Positions()
+
+	qs := make([][]float64, 256)
+
+	for i := range qs {
+		qs[i] = []float64{rng.Float64() * 1000, rng.Float64() * 1000}
+	}
+
+	for _, bm := range []struct {
+		name  string
+		query func(vals []float64) []int
+	}{{"Overlaps", tree.Overlaps}, {"OverlapsLocal", tree.OverlapsLocal}} {
+
+		b.Run(bm.name, func(b *testing.B) {
+
+			sum := 0.0
+
+			for i := 0; i < b.N; i++ {
+
+				for _, idx := range bm.query(qs[i%len(qs)]) {
+					sum += tree.lmts[2*pos[idx]][0]
+				}
+
+			}
+
+			_ = sum
+
+		})
+
+	}
+
+}
+
+func TestOverlapsSampled(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(37))
+	bxs := randomBoxes(rng, 2000, 10, 10)
+	tree := NewBOXTree(bxs)
+	vals := []float64{10, 10}
+	all := bruteOverlaps(bxs, vals)
+
+	a := tree.OverlapsSampledRand(vals, 0.5, rand.New(rand.NewSource(1)))
+	b := tree.OverlapsSampledRand(vals, 0.5, rand.New(rand.NewSource(1)))
+
+	if !equalInts(a, b) {
+		t.Fatal("equally seeded sources yield different samples")
+	}
+
+	if n := len(a); n < len(all)/4 || n > 3*len(all)/4 {
+		t.Fatalf("rate 0.5 sampled %d of %d boxes", n, len(all))
+	}
+
+	if got := sorted(tree.OverlapsSampled(vals, 1)); !equalInts(got, all) {
+		t.Fatalf("rate 1: %d of %d boxes", len(got), len(all))
+	}
+
+	if got := tree.OverlapsSampled(vals, 0); len(got) != 0 {
+		t.Fatalf("rate 0: %d boxes", len(got))
+	}
+
+	for _, idx := range tree.OverlapsSampled(vals, 0.3) {
+
+		if l, u := bxs[idx].Limits(); !within(l, u, vals) {
+			t.Fatalf("sampled box %d does not overlap %v", idx, vals)
+		}
+
+	}
+
+}
+
+func TestOverlapsOnePerCell(t *testing.T) {
+
+	tree := NewBOXTree([]Box{NewRect(0, 0, 2, 2), NewRect(0.5, 0.5, 1.5, 1.5), NewRect(0, 0, 4, 4)})
+
+	if got := tree.OverlapsOnePerCell([]float64{1, 1}, 1.5); len(got) != 2 {
+		t.Fatalf("OverlapsOnePerCell = %v, want one box per cell of 2 cells", got)
+	}
+
+	for _, cs := range []float64{0, -1, math.NaN()} {
+
+		func() {
+
+			defer func() {
+
+				if recover() == nil {
+					t.Fatalf("cell size %v: no panic", cs)
+				}
+
+			}()
+
+			tree.OverlapsOnePerCell([]float64{1, 1}, cs)
+
+		}()
+
+	}
+
+}
+
+func TestOverlapsInRegion(t *testing.T) {
+
+	tree := NewBOXTree([]Box{NewRect(0, 0, 3, 3)})
+
+	if got := tree.OverlapsInRegion([]float64{2, 2}, []float64{0, 0}, []float64{1, 1}); !equalInts(got, []int{0}) {
+		t.Fatalf("intersecting box: OverlapsInRegion = %v, want [0]", got)
+	}
+
+	if got := tree.OverlapsInRegionContained([]float64{2, 2}, []float64{0, 0}, []float64{1, 1}); len(got) != 0 {
+		t.Fatalf("intersecting box: OverlapsInRegionContained = %v, want []", got)
+	}
+
+	rng := rand.New(rand.NewSource(39))
+	bxs := randomBoxes(rng, 2000, 100, 20)
+	tree = NewBOXTree(bxs, WithLeafSize(4))
+
+	for q := 0; q < 300; q++ {
+
+		vals := []float64{rng.Float64() * 110, rng.Float64() * 110}
+		rl := []float64{rng.Float64() * 100, rng.Float64() * 100}
+		ru := []float64{rl[0] + rng.Float64()*30, rl[1] + rng.Float64()*30}
+
+		inter, cont := []int{}, []int{}
+
+		for _, i := range bruteOverlaps(bxs, vals) {
+
+			l, u := bxs[i].Limits()
+
+			if intersects(l, u, rl, ru) {
+				inter = append(inter, i)
+			}
+
+			if within(rl, ru, l) && within(rl, ru, u) {
+				cont = append(cont, i)
+			}
+
+		}
+
+		if got := sorted(tree.OverlapsInRegion(vals, rl, ru)); !equalInts(got, inter) {
+			t.Fatalf("OverlapsInRegion(%v, %v, %v) = %v, want %v", vals, rl, ru, got, inter)
+		}
+
+		if got := sorted(tree.OverlapsInRegionContained(vals, rl, ru)); !equalInts(got, cont) {
+			t.Fatalf("OverlapsInRegionContained(%v, %v, %v) = %v, want %v", vals, rl, ru, got, cont)
+		}
+
+	}
+
+}
+
+func TestOverlapsChanCancel(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(46))
+	bxs := make([]Box, 1000)
+
+	for i := range bxs {
+
+		x, y := rng.Float64()*10, rng.Float64()*10
+		bxs[i] = &testBox{[]float64{x, y}, []float64{x + 50, y + 50}}
+
+	}
+
+	tree := NewBOXTree(bxs)
+	vals := []float64{30, 30}
+	want := bruteOverlaps(bxs, vals)
+
+	got := []int{}
+
+	for idx := range tree.OverlapsChan(context.Background(), vals) {
+		got = append(got, idx)
+	}
+
+	if got = sorted(got); !equalInts(got, want) {
+		t.Fatalf("drained OverlapsChan(%v) = %d results, want %d", vals, len(got), len(want))
+	}
+
+	base := runtime.NumGoroutine()
+
+	for r := 0; r < 20; r++ {
+
+		ctx, cancel := context.WithCancel(context.Background())
+		ch := tree.OverlapsChan(ctx, vals)
+
+		for n := 0; n < 10; n++ {
+			<-ch
+		}
+
+		cancel()
+
+		// sends still racing the cancellation may get through, but the traversal must stop and close the channel
+		n := 10
+
+		for range ch {
+			n++
+		}
+
+		if n >= len(want) {
+			t.Fatalf("round %d: all %d values received despite cancel", r, n)
+		}
+
+	}
+
+	for i := 0; runtime.NumGoroutine() > base; i++ {
+
+		if i == 100 {
+			t.Fatalf("%d goroutines after cancelled queries, want %d", runtime.NumGoroutine(), base)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+
+	}
+
+}
+
+func TestOverlapsBoxBoundsSharedEdges(t *testing.T) {
+
+	// each of the first four boxes shares exactly one edge with the query box {1, 1}, {2, 2}: its lower x, lower y,
+	// upper x and upper y bound in that order; the last one lies inside
+	tree := NewBOXTree([]Box{
+		&testBox{[]float64{0, 1.2}, []float64{1, 1.8}},
+		&testBox{[]float64{1.2, 0}, []float64{1.8, 1}},
+		&testBox{[]float64{2, 1.2}, []float64{3, 1.8}},
+		&testBox{[]float64{1.2, 2}, []float64{1.8, 3}},
+		&testBox{[]float64{1.2, 1.2}, []float64{1.8, 1.8}},
+	})
+
+	for _, tc := range []struct {
+		lowerClosed, upperClosed []bool
+		want                     []int
+	}{
+		{[]bool{false, false}, []bool{false, false}, []int{4}},
+		{[]bool{true, false}, []bool{false, false}, []int{0, 4}},
+		{[]bool{false, true}, []bool{false, false}, []int{1, 4}},
+		{[]bool{true, true}, []bool{false, false}, []int{0, 1, 4}},
+		{[]bool{false, false}, []bool{true, false}, []int{2, 4}},
+		{[]bool{true, false}, []bool{true, false}, []int{0, 2, 4}},
+		{[]bool{false, true}, []bool{true, false}, []int{1, 2, 4}},
+		{[]bool{true, true}, []bool{true, false}, []int{0, 1, 2, 4}},
+		{[]bool{false, false}, []bool{false, true}, []int{3, 4}},
+		{[]bool{true, false}, []bool{false, true}, []int{0, 3, 4}},
+		{[]bool{false, true}, []bool{false, true}, []int{1, 3, 4}},
+		{[]bool{true, true}, []bool{false, true}, []int{0, 1, 3, 4}},
+		{[]bool{false, false}, []bool{true, true}, []int{2, 3, 4}},
+		{[]bool{true, false}, []bool{true, true}, []int{0, 2, 3, 4}},
+		{[]bool{false, true}, []bool{true, true}, []int{1, 2, 3, 4}},
+		{[]bool{true, true}, []bool{true, true}, []int{0, 1, 2, 3, 4}},
+	} {
+
+		if got := sorted(tree.OverlapsBoxBounds([]float64{1, 1}, []float64{2, 2}, tc.lowerClosed, tc.upperClosed)); !equalInts(got, tc.want) {
+			t.Fatalf("OverlapsBoxBounds(%v, %v) = %v, want %v", tc.lowerClosed, tc.upperClosed, got, tc.want)
+		}
+
+	}
+
+}
+
+func TestOverlapsTouchSplitCorners(t *testing.T) {
+
+	tree := NewBOXTree([]Box{
+		&testBox{[]float64{0, 0}, []float64{2, 2}},
+		&testBox{[]float64{2, 0}, []float64{4, 2}},
+		&testBox{[]float64{5, 0}, []float64{5, 2}},
+	})
+
+	for _, tc := range []struct {
+		vals               []float64
+		inside, onBoundary []int
+	}{
+		{[]float64{1, 1}, []int{0}, []int{}},
+		{[]float64{0, 1}, []int{}, []int{0}},
+		{[]float64{1, 2}, []int{}, []int{0}},
+		{[]float64{0, 0}, []int{}, []int{0}},
+		// the shared edge of the two adjacent boxes, and its corner
+		{[]float64{2, 1}, []int{}, []int{0, 1}},
+		{[]float64{2, 2}, []int{}, []int{0, 1}},
+		// one ulp off an edge is inside or outside, never on it
+		{[]float64{math.Nextafter(0, 1), 1}, []int{0}, []int{}},
+		{[]float64{math.Nextafter(0, -1), 1}, []int{}, []int{}},
+		{[]float64{math.Nextafter(2, 3), 1}, []int{1}, []int{}},
+		// a box degenerated to a line is always touched on its boundary
+		{[]float64{5, 1}, []int{}, []int{2}},
+		{[]float64{4.5, 1}, []int{}, []int{}},
+	} {
+
+		in, on := tree.OverlapsTouchSplit(tc.vals)
+
+		if !equalInts(sorted(in), tc.inside) || !equalInts(sorted(on), tc.onBoundary) {
+			t.Fatalf("OverlapsTouchSplit(%v) = %v, %v, want %v, %v", tc.vals, in, on, tc.inside, tc.onBoundary)
+		}
+
+	}
+
+	rng := rand.New(rand.NewSource(56))
+	bxs := make([]Box, 1000)
+
+	// integer limits, so that integer query values regularly hit edges exactly
+	for i := range bxs {
+
+		x, y := float64(rng.Intn(100)), float64(rng.Intn(100))
+		bxs[i] = &testBox{[]float64{x, y}, []float64{x + float64(rng.Intn(10)), y + float64(rng.Intn(10))}}
+
+	}
+
+	tree = NewBOXTree(bxs)
+
+	for q := 0; q < 300; q++ {
+
+		vals := []float64{float64(rng.Intn(110)), float64(rng.Intn(110))}
+		in, on := tree.OverlapsTouchSplit(vals)
+
+		if got, want := sorted(append(append([]int{}, in...), on...)), bruteOverlaps(bxs, vals); !equalInts(got, want) {
+			t.Fatalf("OverlapsTouchSplit(%v) = %v, want %v", vals, got, want)
+		}
+
+		for _, idx := range on {
+
+			if l, u := bxs[idx].Limits(); vals[0] != l[0] && vals[0] != u[0] && vals[1] != l[1] && vals[1] != u[1] {
+				t.Fatalf("OverlapsTouchSplit(%v): box %d %v %v reported on boundary", vals, idx, l, u)
+			}
+
+		}
+
+	}
+
+}
+
+func TestOverlapsOrderedGolden(t *testing.T) {
+
+	bxs := []Box{
+		&testBox{[]float64{4, 6}, []float64{8, 10}},
+		&testBox{[]float64{5, 5}, []float64{11, 9}},
+		&testBox{[]float64{1, 4}, []float64{4, 7}},
+		&testBox{[]float64{2, 3}, []float64{3, 4}},
+		&testBox{[]float64{4, 6}, []float64{8, 10}},
+		&testBox{[]float64{6, 3}, []float64{8, 8}},
+		&testBox{[]float64{2, 6}, []float64{7, 7}},
+	}
+
+	for _, tc := range []struct {
+		vals []float64
+		want []int
+	}{
+		{[]float64{3.2, 6.3}, []int{2, 6}},
+		{[]float64{6.5, 6.5}, []int{0, 1, 4, 5, 6}},
+		{[]float64{4, 6}, []int{0, 2, 4, 6}},
+		{[]float64{20, 20}, []int{}},
+	} {
+
+		for r := 0; r < 10; r++ {
+
+			if got := NewBOXTree(bxs, WithLeafSize(r%3*2)).OverlapsOrdered(tc.vals); !equalInts(got, tc.want) {
+				t.Fatalf("run %d: OverlapsOrdered(%v) = %v, want %v", r, tc.vals, got, tc.want)
+			}
+
+		}
+
+	}
+
+	for seed := int64(1); seed <= 5; seed++ {
+
+		rng := rand.New(rand.NewSource(seed))
+		bxs := randomBoxes(rng, 1000, 100, 10)
+		trees := []*BOXTree{NewBOXTree(bxs), NewBOXTree(bxs, WithLeafSize(8)), NewBOXTree(bxs, WithDedup())}
+
+		for q := 0; q < 100; q++ {
+
+			vals := []float64{rng.Float64() * 110, rng.Float64() * 110}
+			want := bruteOverlaps(bxs, vals)
+
+			for i, tree := range trees {
+
+				if got := tree.OverlapsOrdered(vals); !equalInts(got, want) {
+					t.Fatalf("seed %d, tree %d: OverlapsOrdered(%v) = %v, want %v", seed, i, vals, got, want)
+				}
+
+			}
+
+		}
+
+	}
+
+}
+
+func TestOverlapsHitKindEachKind(t *testing.T) {
+
+	tree := NewBOXTree([]Box{
+		&testBox{[]float64{0, 0}, []float64{2, 2}},
+		&testBox{[]float64{1, 1}, []float64{3, 3}},
+		&testBox{[]float64{4, 4}, []float64{4, 4}},
+	})
+
+	for _, tc := range []struct {
+		vals []float64
+		want []HitResult
+	}{
+		{[]float64{0.5, 0.5}, []HitResult{{0, Interior}}},
+		{[]float64{1.5, 1.5}, []HitResult{{0, Interior}, {1, Interior}}},
+		{[]float64{0, 0.5}, []HitResult{{0, Edge}}},
+		{[]float64{0.5, 2}, []HitResult{{0, Edge}}},
+		{[]float64{2, 0}, []HitResult{{0, Corner}}},
+		{[]float64{1, 1}, []HitResult{{0, Interior}, {1, Corner}}},
+		{[]float64{2, 1.5}, []HitResult{{0, Edge}, {1, Interior}}},
+		{[]float64{2, 2}, []HitResult{{0, Corner}, {1, Interior}}},
+		{[]float64{1, 2}, []HitResult{{0, Edge}, {1, Edge}}},
+		// a point box is hit on its corner only
+		{[]float64{4, 4}, []HitResult{{2, Corner}}},
+		{[]float64{3.5, 3.5}, []HitResult{}},
+	} {
+
+		got := tree.OverlapsHitKind(tc.vals)
+
+		gosort.Slice(got, func(i, j int) bool {
+			return got[i].Index < got[j].Index
+		})
+
+		if len(got) != len(tc.want) {
+			t.Fatalf("OverlapsHitKind(%v) = %v, want %v", tc.vals, got, tc.want)
+		}
+
+		for i := range got {
+
+			if got[i] != tc.want[i] {
+				t.Fatalf("OverlapsHitKind(%v) = %v, want %v", tc.vals, got, tc.want)
+			}
+
+		}
+
+	}
+
+}
+
+func TestOverlapsWithMarginBoundary(t *testing.T) {
+
+	tree := NewBOXTree([]Box{
+		&testBox{[]float64{0, 0}, []float64{2, 2}},
+		&testBox{[]float64{5, 0}, []float64{6, 2}},
+	})
+
+	for _, tc := range []struct {
+		vals       []float64
+		margin     float64
+		hits, near []int
+	}{
+		{[]float64{1, 1}, 0.5, []int{0}, []int{}},
+		{[]float64{2, 1}, 0.5, []int{0}, []int{}},
+		// 0.5 away from box 0: just inside, exactly on and just outside the margin
+		{[]float64{2.5, 1}, 0.5000001, []int{}, []int{0}},
+		{[]float64{2.5, 1}, 0.5, []int{}, []int{0}},
+		{[]float64{2.5, 1}, 0.4999999, []int{}, []int{}},
+		// 3 and 4 away diagonally from the corner of box 0: Euclidean distance 5, not the per-axis gap
+		{[]float64{-3, 6}, 4.9999, []int{}, []int{}},
+		{[]float64{-3, 6}, 5, []int{}, []int{0}},
+		{[]float64{3.5, 1}, 1.5, []int{}, []int{0, 1}},
+		{[]float64{5.5, 1}, 4, []int{1}, []int{0}},
+	} {
+
+		hits, near := tree.OverlapsWithMargin(tc.vals, tc.margin)
+
+		if !equalInts(sorted(hits), tc.hits) || !equalInts(sorted(near), tc.near) {
+			t.Fatalf("OverlapsWithMargin(%v, %v) = %v, %v, want %v, %v", tc.vals, tc.margin, hits, near, tc.hits, tc.near)
+		}
+
+	}
+
+	rng := rand.New(rand.NewSource(59))
+	bxs := randomBoxes(rng, 1000, 100, 10)
+	rnd := NewBOXTree(bxs)
+
+	for q := 0; q < 300; q++ {
+
+		vals, margin := []float64{rng.Float64() * 110, rng.Float64() * 110}, rng.Float64()*5
+		hits, near := rnd.OverlapsWithMargin(vals, margin)
+		want := []int{}
+
+		for i, bx := range bxs {
+
+			if l, u := bx.Limits(); distance(l, u, vals) > 0 && distance(l, u, vals) <= margin {
+				want = append(want, i)
+			}
+
+		}
+
+		if !equalInts(sorted(hits), bruteOverlaps(bxs, vals)) || !equalInts(sorted(near), want) {
+			t.Fatalf("OverlapsWithMargin(%v, %v) = %v, %v, want %v, %v", vals, margin, hits, near, bruteOverlaps(bxs, vals), want)
+		}
+
+	}
+
+}
+
+func TestOverlapMatrixMatchesBruteForce(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(60))
+	bxs := randomBoxes(rng, 1000, 100, 10)
+	queries := make([][2][]float64, 300)
+
+	for i := range queries {
+
+		x, y := rng.Float64()*110, rng.Float64()*110
+		queries[i] = [2][]float64{{x, y}, {x + rng.Float64()*15, y + rng.Float64()*15}}
+
+	}
+
+	// a degenerate query box is a point query
+	queries = append(queries, [2][]float64{{50, 50}, {50, 50}})
+
+	for _, lf := range []int{0, 8} {
+
+		rows := NewBOXTree(bxs, WithLeafSize(lf)).OverlapMatrix(queries)
+
+		if len(rows) != len(queries) {
+			t.Fatalf("leaf %d: %d rows for %d queries", lf, len(rows), len(queries))
+		}
+
+		for i, q := range queries {
+
+			want := []int{}
+
+			for j, bx := range bxs {
+
+				if l, u := bx.Limits(); intersects(l, u, q[0], q[1]) {
+					want = append(want, j)
+				}
+
+			}
+
+			if got := sorted(rows[i]); !equalInts(got, want) {
+				t.Fatalf("leaf %d, row %d %v: %v, want %v", lf, i, q, got, want)
+			}
+
+		}
+
+	}
+
+}
+
+func BenchmarkOverlapMatrix(b *testing.B) {
+
+	rng := rand.New(rand.NewSource(3))
+	tree := NewBOXTree(randomBoxes(rng, 100000, 1000, 10))
+	queries := make([][2][]float64, 256)
+
+	for i := range queries {
+
+		x, y := rng.Float64()*1000, rng.Float64()*1000
+		queries[i] = [2][]float64{{x, y}, {x + 20, y + 20}}
+
+	}
+
+	b.Run("Matrix", func(b *testing.B) {
+
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			tree.OverlapMatrix(queries)
+		}
+
+	})
+
+	// one box query per call, as a loop over single box searches would run
+	b.Run("Loop", func(b *testing.B) {
+
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+
+			for j := range queries {
+				tree.OverlapMatrix(queries[j : j+1])
+			}
+
+		}
+
+	})
+
+}
+
+func TestOverlapsDeepestNested(t *testing.T) {
+
+	// all boxes share their y limits, so the tree is leveled on x only and node positions follow the lower x limits:
+	// the middle box is the root, the outer one a child of it and the innermost one a grandchild
+	tree := NewBOXTree([]Box{
+		&testBox{[]float64{3, 0}, []float64{4, 1}},
+		&testBox{[]float64{7, 0}, []float64{8, 1}},
+		&testBox{[]float64{1, 0}, []float64{10, 1}},
+		&testBox{[]float64{0, 0}, []float64{0.5, 1}},
+		&testBox{[]float64{2, 0}, []float64{9, 1}},
+		&testBox{[]float64{5, 0}, []float64{6, 1}},
+		&testBox{[]float64{1.5, 0}, []float64{1.6, 1}},
+	})
+
+	all, deepest := tree.OverlapsDeepest([]float64{3.5, 0.5})
+
+	if !equalInts(sorted(all), []int{0, 2, 4}) || deepest != 0 {
+		t.Fatalf("OverlapsDeepest = %v, %d, want [0 2 4], 0", all, deepest)
+	}
+
+	if all, deepest := tree.OverlapsDeepest([]float64{20, 0.5}); len(all) != 0 || deepest != -1 {
+		t.Fatalf("OverlapsDeepest without matches = %v, %d, want [], -1", all, deepest)
+	}
+
+	rng := rand.New(rand.NewSource(63))
+
+	for _, lf := range []int{0, 8} {
+
+		bxs := randomBoxes(rng, 1000, 100, 15)
+		tree := NewBOXTree(bxs, WithLeafSize(lf))
+		pos := tree.Positions()
+
+		for q := 0; q < 300; q++ {
+
+			vals := []float64{rng.Float64() * 110, rng.Float64() * 110}
+			all, deepest := tree.OverlapsDeepest(vals)
+			want := bruteOverlaps(bxs, vals)
+
+			if !equalInts(sorted(all), want) {
+				t.Fatalf("leaf %d, OverlapsDeepest(%v) = %v, want %v", lf, vals, all, want)
+			}
+
+			if len(want) == 0 {
+
+				if deepest != -1 {
+					t.Fatalf("leaf %d, OverlapsDeepest(%v) deepest = %d, want -1", lf, vals, deepest)
+				}
+
+				continue
+
+			}
+
+			for _, idx := range want {
+
+				if tree.level(pos[idx]) > tree.level(pos[deepest]) {
+					t.Fatalf("leaf %d, OverlapsDeepest(%v): box %d lies deeper than reported box %d", lf, vals, idx, deepest)
+				}
+
+			}
+
+		}
+
+	}
+
+}
+
+func TestOverlapsHashPermutation(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(66))
+	bxs := randomBoxes(rng, 1000, 100, 15)
+	trees := []*BOXTree{NewBOXTree(bxs), NewBOXTree(bxs, WithLeafSize(8)), NewBOXTree(bxs, WithColumnar())}
+
+	hash := func(idxs []int) uint64 {
+
+		var h uint64
+
+		for _, idx := range idxs {
+			h ^= mix(uint64(idx))
+		}
+
+		return h
+
+	}
+
+	for q := 0; q < 300; q++ {
+
+		vals := []float64{rng.Float64() * 110, rng.Float64() * 110}
+		want := bruteOverlaps(bxs, vals)
+		h := hash(want)
+
+		// any order of the same result set yields the same hash
+		rng.Shuffle(len(want), func(i, j int) {
+			want[i], want[j] = want[j], want[i]
+		})
+
+		if hash(want) != h {
+			t.Fatalf("hash of shuffled %v differs", want)
+		}
+
+		for i, tree := range trees {
+
+			if got := tree.OverlapsHash(vals); got != h {
+				t.Fatalf("tree %d: OverlapsHash(%v) = %x, want %x", i, vals, got, h)
+			}
+
+		}
+
+		if len(want) > 0 && hash(want[1:]) == h {
+			t.Fatalf("hash of %v unchanged by dropping a result", want)
+		}
+
+	}
+
+	if h := trees[0].OverlapsHash([]float64{-50, -50}); h != 0 {
+		t.Fatalf("OverlapsHash without matches = %x, want 0", h)
+	}
+
+}
+
+func TestOverlapsScaled(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(69))
+	bxs := randomBoxes(rng, 1000, 100, 10)
+	tree := NewBOXTree(bxs)
+	scale, offset := []float64{2, 2}, []float64{-10, 5}
+
+	for q := 0; q < 300; q++ {
+
+		// values in units of half the tree's, shifted
+		vals := []float64{rng.Float64() * 60, rng.Float64() * 60}
+		want := bruteOverlaps(bxs, []float64{vals[0]*2 - 10, vals[1]*2 + 5})
+
+		if got := sorted(tree.OverlapsScaled(vals, scale, offset)); !equalInts(got, want) {
+			t.Fatalf("OverlapsScaled(%v) = %v, want %v", vals, got, want)
+		}
+
+	}
+
+	// 5.25, -2.25 maps to 0.5, 0.5, inside the unit box, which the unscaled values miss
+	unit := NewBOXTree([]Box{&testBox{[]float64{0, 0}, []float64{1, 1}}})
+	vals := []float64{5.25, -2.25}
+
+	if got := unit.OverlapsScaled(vals, scale, offset); !equalInts(got, []int{0}) {
+		t.Fatalf("OverlapsScaled(%v) = %v, want [0]", vals, got)
+	}
+
+	if got := unit.Overlaps(vals); len(got) != 0 || vals[0] != 5.25 || vals[1] != -2.25 {
+		t.Fatalf("Overlaps(%v) = %v after OverlapsScaled, want unchanged values and no match", vals, got)
+	}
+
+}
+
+func TestOverlapsFuncLimits(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(71))
+	bxs := randomBoxes(rng, 1000, 100, 15)
+	tree := NewBOXTree(bxs)
+
+	for q := 0; q < 300; q++ {
+
+		vals := []float64{rng.Float64() * 110, rng.Float64() * 110}
+		got := []int{}
+
+		tree.OverlapsFuncLimits(vals, func(idx int, lower, upper []float64) bool {
+
+			if l, u := bxs[idx].Limits(); lower[0] != l[0] || lower[1] != l[1] || upper[0] != u[0] || upper[1] != u[1] {
+				t.Fatalf("OverlapsFuncLimits(%v): box %d passed %v %v, stored %v %v", vals, idx, lower, upper, l, u)
+			}
+
+			got = append(got, idx)
+			return true
+
+		})
+
+		want := bruteOverlaps(bxs, vals)
+
+		if !equalInts(sorted(got), want) {
+			t.Fatalf("OverlapsFuncLimits(%v) = %v, want %v", vals, sorted(got), want)
+		}
+
+		n := 0
+
+		tree.OverlapsFuncLimits(vals, func(int, []float64, []float64) bool {
+
+			n++
+			return n < 2
+
+		})
+
+		if len(want) >= 2 && n != 2 || len(want) < 2 && n != len(want) {
+			t.Fatalf("OverlapsFuncLimits(%v) stopping after 2: %d calls for %d matches", vals, n, len(want))
+		}
+
+	}
+
+}
+
+func TestTopKByOverlapAreaMatchesBruteForce(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(72))
+	bxs := randomBoxes(rng, 1000, 100, 15)
+	tree := NewBOXTree(bxs)
+
+	for q := 0; q < 300; q++ {
+
+		x, y := rng.Float64()*110, rng.Float64()*110
+		lower, upper := []float64{x, y}, []float64{x + rng.Float64()*20, y + rng.Float64()*20}
+		all := []AreaMatch{}
+
+		for i, bx := range bxs {
+
+			if l, u := bx.Limits(); intersects(l, u, lower, upper) {
+				all = append(all, AreaMatch{i, (math.Min(u[0], upper[0]) - math.Max(l[0], lower[0])) * (math.Min(u[1], upper[1]) - math.Max(l[1], lower[1]))})
+			}
+
+		}
+
+		gosort.Slice(all, func(i, j int) bool {
+
+			if all[i].Area != all[j].Area {
+				return all[i].Area > all[j].Area
+			}
+
+			return all[i].Index < all[j].Index
+
+		})
+
+		for _, k := range []int{0, 1, 5, len(all) + 3} {
+
+			want := all
+
+			if k < len(all) {
+				want = all[:k]
+			}
+
+			got := tree.TopKByOverlapArea(lower, upper, k)
+
+			if len(got) != len(want) {
+				t.Fatalf("TopKByOverlapArea(%v, %v, %d) = %v, want %v", lower, upper, k, got, want)
+			}
+
+			for i := range got {
+
+				if got[i] != want[i] {
+					t.Fatalf("TopKByOverlapArea(%v, %v, %d) = %v, want %v", lower, upper, k, got, want)
+				}
+
+			}
+
+		}
+
+	}
+
+}
+
+func TestOverlapsMBRMatchesBruteForce(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(73))
+	bxs := randomBoxes(rng, 1000, 100, 15)
+	tree := NewBOXTree(bxs)
+
+	for q := 0; q < 300; q++ {
+
+		vals := []float64{rng.Float64() * 120, rng.Float64() * 120}
+		want := bruteOverlaps(bxs, vals)
+		lower, upper, n := tree.OverlapsMBR(vals)
+
+		if n != len(want) {
+			t.Fatalf("OverlapsMBR(%v): n = %d, want %d", vals, n, len(want))
+		}
+
+		if n == 0 {
+
+			if lower != nil || upper != nil {
+				t.Fatalf("OverlapsMBR(%v) without matches = %v %v, want nil", vals, lower, upper)
+			}
+
+			continue
+
+		}
+
+		wl, wu := []float64{math.Inf(1), math.Inf(1)}, []float64{math.Inf(-1), math.Inf(-1)}
+
+		for _, idx := range want {
+
+			l, u := bxs[idx].Limits()
+
+			for ax := 0; ax < 2; ax++ {
+				wl[ax], wu[ax] = math.Min(wl[ax], l[ax]), math.Max(wu[ax], u[ax])
+			}
+
+		}
+
+		if lower[0] != wl[0] || lower[1] != wl[1] || upper[0] != wu[0] || upper[1] != wu[1] {
+			t.Fatalf("OverlapsMBR(%v) = %v %v, want %v %v", vals, lower, upper, wl, wu)
+		}
+
+		// the result must not alias the stored limits
+		lower[0] = math.NaN()
+
+		if l, _, _ := tree.OverlapsMBR(vals); math.IsNaN(l[0]) {
+			t.Fatalf("OverlapsMBR(%v) returned stored limits", vals)
+		}
+
+	}
+
+}
+
+// visitCounter is a Tracer counting visited nodes.
+type visitCounter struct {
+	visits int
+}
+
+func (vc *visitCounter) OnQueryStart([]float64) {}
+
+func (vc *visitCounter) OnNodeVisit(int) {
+	vc.visits++
+}
+
+func (vc *visitCounter) OnQueryEnd(int) {}
+
+func TestOverlapsUntilPrunes(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(74))
+	bxs := make([]Box, 5000)
+
+	// every box contains the center, so a full traversal visits all of them
+	for i := range bxs {
+
+		x, y := rng.Float64()*50, rng.Float64()*50
+		bxs[i] = &testBox{[]float64{x, y}, []float64{x + 50, y + 50}}
+
+	}
+
+	tree := NewBOXTree(bxs)
+	vc := &visitCounter{}
+	tree.SetTracer(vc)
+
+	vals := []float64{50, 50}
+	calls := 0
+
+	tree.OverlapsUntil(vals, func(int) bool {
+
+		calls++
+		return false
+
+	})
+
+	full := vc.visits
+
+	if calls != len(bxs) {
+		t.Fatalf("OverlapsUntil without stop: %d calls, want %d", calls, len(bxs))
+	}
+
+	for _, n := range []int{1, 10, 100} {
+
+		vc.visits, calls = 0, 0
+
+		tree.OverlapsUntil(vals, func(int) bool {
+
+			calls++
+			return calls == n
+
+		})
+
+		if calls != n {
+			t.Fatalf("OverlapsUntil stopping at %d: %d calls", n, calls)
+		}
+
+		// every visited node matches, so none but those already passed to step may have been visited
+		if vc.visits > n || vc.visits*10 > full {
+			t.Fatalf("OverlapsUntil stopping at %d: %d of %d nodes visited", n, vc.visits, full)
+		}
+
+	}
+
+}
+
+func TestOverlapsEdgeDistance(t *testing.T) {
+
+	tree := NewBOXTree([]Box{
+		&testBox{[]float64{0, 0}, []float64{10, 4}},
+		&testBox{[]float64{8, 1}, []float64{12, 3}},
+	})
+
+	for _, tc := range []struct {
+		vals []float64
+		want []EdgeMatch
+	}{
+		// the center of box 0, nearest to its long edges
+		{[]float64{5, 2}, []EdgeMatch{{0, 2}}},
+		{[]float64{9.5, 2}, []EdgeMatch{{0, 0.5}, {1, 1}}},
+		{[]float64{0.25, 2}, []EdgeMatch{{0, 0.25}}},
+		{[]float64{10, 2}, []EdgeMatch{{0, 0}, {1, 1}}},
+		{[]float64{8, 3}, []EdgeMatch{{0, 1}, {1, 0}}},
+	} {
+
+		got := tree.OverlapsEdgeDistance(tc.vals)
+
+		gosort.Slice(got, func(i, j int) bool {
+			return got[i].Index < got[j].Index
+		})
+
+		if len(got) != len(tc.want) {
+			t.Fatalf("OverlapsEdgeDistance(%v) = %v, want %v", tc.vals, got, tc.want)
+		}
+
+		for i := range got {
+
+			if got[i] != tc.want[i] {
+				t.Fatalf("OverlapsEdgeDistance(%v) = %v, want %v", tc.vals, got, tc.want)
+			}
+
+		}
+
+	}
+
+}
+
+func TestOverlapsBucketed(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(75))
+	bxs := randomBoxes(rng, 1000, 100, 10)
+	tree := NewBOXTree(bxs)
+
+	// bucket -1 and 3 fall outside the range and are dropped
+	bucket := func(idx int) int {
+		return idx%5 - 1
+	}
+
+	for q := 0; q < 300; q++ {
+
+		vals := []float64{rng.Float64() * 110, rng.Float64() * 110}
+		got := tree.OverlapsBucketed(vals, bucket, 3)
+		want := make([][]int, 3)
+
+		for _, idx := range bruteOverlaps(bxs, vals) {
+
+			if b := bucket(idx); b >= 0 && b < 3 {
+				want[b] = append(want[b], idx)
+			}
+
+		}
+
+		if len(got) != 3 {
+			t.Fatalf("OverlapsBucketed(%v): %d buckets, want 3", vals, len(got))
+		}
+
+		for b := range got {
+
+			if got[b] == nil || !equalInts(sorted(got[b]), sorted(want[b])) {
+				t.Fatalf("OverlapsBucketed(%v) bucket %d = %v, want %v", vals, b, got[b], want[b])
+			}
+
+		}
+
+	}
+
+	if got := tree.OverlapsBucketed([]float64{50, 50}, bucket, -1); len(got) != 0 {
+		t.Fatalf("OverlapsBucketed with negative count = %v, want none", got)
+	}
+
+}
+
+func TestOverlapsCentroidHandComputed(t *testing.T) {
+
+	tree := NewBOXTree([]Box{
+		&testBox{[]float64{0, 0}, []float64{4, 2}},
+		&testBox{[]float64{2, 1}, []float64{4, 5}},
+		&testBox{[]float64{3, 0}, []float64{5, 1}},
+	})
+
+	for _, tc := range []struct {
+		vals []float64
+		want []float64
+		n    int
+	}{
+		// areas 8, 8 and 2 at centers (2, 1), (3, 3) and (4, 0.5)
+		{[]float64{3, 1}, []float64{48.0 / 18.0, 33.0 / 18.0}, 3},
+		{[]float64{3, 2}, []float64{2.5, 2}, 2},
+		{[]float64{1, 1}, []float64{2, 1}, 1},
+		{[]float64{10, 10}, nil, 0},
+	} {
+
+		c, n := tree.OverlapsCentroid(tc.vals)
+
+		if n != tc.n || len(c) != len(tc.want) || (n > 0 && (math.Abs(c[0]-tc.want[0]) > 1e-12 || math.Abs(c[1]-tc.want[1]) > 1e-12)) {
+			t.Fatalf("OverlapsCentroid(%v) = %v, %d, want %v, %d", tc.vals, c, n, tc.want, tc.n)
+		}
+
+	}
+
+	// zero area matches are averaged unweighted
+	segs := NewBOXTree([]Box{
+		&testBox{[]float64{0, 1}, []float64{4, 1}},
+		&testBox{[]float64{1, 0}, []float64{1, 4}},
+	})
+
+	if c, n := segs.OverlapsCentroid([]float64{1, 1}); n != 2 || c[0] != 1.5 || c[1] != 1.5 {
+		t.Fatalf("OverlapsCentroid over segments = %v, %d, want [1.5 1.5], 2", c, n)
+	}
+
+}
+
+func TestOverlapsAxisMatchesBruteForce(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(76))
+	bxs := randomBoxes(rng, 1000, 100, 10)
+
+	for _, opts := range [][]Option{{}, {WithLeafSize(8)}} {
+
+		tree := NewBOXTree(bxs, opts...)
+
+		for q := 0; q < 300; q++ {
+
+			ax, v := q%2, rng.Float64()*110
+
+			// every tenth query sits exactly on a stored limit
+			if q%10 == 0 {
+
+				l, u := bxs[rng.Intn(len(bxs))].Limits()
+				v = [2][]float64{l, u}[q/10%2][ax]
+
+			}
+
+			want := []int{}
+
+			for i, bx := range bxs {
+
+				if l, u := bx.Limits(); l[ax] <= v && v <= u[ax] {
+					want = append(want, i)
+				}
+
+			}
+
+			if got := sorted(tree.OverlapsAxis(ax, v)); !equalInts(got, want) {
+				t.Fatalf("OverlapsAxis(%d, %v) = %v, want %v", ax, v, got, want)
+			}
+
+		}
+
+	}
+
+}
+
+func TestOverlapsEncodeRoundTrip(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(77))
+	bxs := randomBoxes(rng, 1000, 100, 10)
+	tree := NewBOXTree(bxs)
+
+	// each record is a length prefix followed by the index and the four limits
+	enc := func(buf *bytes.Buffer) func(int, []float64, []float64) error {
+
+		return func(idx int, lower, upper []float64) error {
+
+			rec := []float64{float64(idx), lower[0], lower[1], upper[0], upper[1]}
+
+			if err := binary.Write(buf, binary.LittleEndian, uint32(8*len(rec))); err != nil {
+				return err
+			}
+
+			return binary.Write(buf, binary.LittleEndian, rec)
+
+		}
+
+	}
+
+	for q := 0; q < 300; q++ {
+
+		vals := []float64{rng.Float64() * 110, rng.Float64() * 110}
+		buf := &bytes.Buffer{}
+
+		if err := tree.OverlapsEncode(vals, enc(buf)); err != nil {
+			t.Fatalf("OverlapsEncode(%v): %v", vals, err)
+		}
+
+		got := []int{}
+
+		for buf.Len() > 0 {
+
+			var n uint32
+			binary.Read(buf, binary.LittleEndian, &n)
+
+			rec := make([]float64, n/8)
+			binary.Read(buf, binary.LittleEndian, rec)
+
+			idx := int(rec[0])
+			l, u := bxs[idx].Limits()
+
+			if rec[1] != l[0] || rec[2] != l[1] || rec[3] != u[0] || rec[4] != u[1] {
+				t.Fatalf("OverlapsEncode(%v): record %v does not match box %d limits %v %v", vals, rec, idx, l, u)
+			}
+
+			got = append(got, idx)
+
+		}
+
+		if want := bruteOverlaps(bxs, vals); !equalInts(sorted(got), want) {
+			t.Fatalf("OverlapsEncode(%v) decoded %v, want %v", vals, got, want)
+		}
+
+	}
+
+	// the first error stops the traversal and is returned as is
+	errStop := errors.New("stop")
+	calls := 0
+
+	err := tree.OverlapsEncode(bxs[0].(*testBox).lower, func(int, []float64, []float64) error {
+
+		calls++
+		return errStop
+
+	})
+
+	if err != errStop || calls != 1 {
+		t.Fatalf("OverlapsEncode with failing encoder = %v after %d calls, want %v after 1", err, calls, errStop)
+	}
+
+}
+
+func TestOverlapsMinimalNested(t *testing.T) {
+
+	tree := NewBOXTree([]Box{
+		&testBox{[]float64{0, 0}, []float64{10, 10}},
+		&testBox{[]float64{2, 2}, []float64{8, 8}},
+		&testBox{[]float64{4, 4}, []float64{6, 6}},
+		&testBox{[]float64{5, 5}, []float64{9, 9}},
+		&testBox{[]float64{4, 4}, []float64{6, 6}},
+	})
+
+	for _, tc := range []struct {
+		vals []float64
+		want []int
+	}{
+		{[]float64{1, 1}, []int{0}},
+		{[]float64{3, 3}, []int{1}},
+		// both copies of the innermost box, but not their containers
+		{[]float64{4.5, 4.5}, []int{2, 4}},
+		// box 3 only partially overlaps boxes 1, 2 and 4, so is kept alongside the copies
+		{[]float64{5.5, 5.5}, []int{2, 3, 4}},
+		{[]float64{8.5, 8.5}, []int{3}},
+		{[]float64{11, 11}, []int{}},
+	} {
+
+		if got := sorted(tree.OverlapsMinimal(tc.vals)); !equalInts(got, tc.want) {
+			t.Fatalf("OverlapsMinimal(%v) = %v, want %v", tc.vals, got, tc.want)
+		}
+
+	}
+
+}
+
+func TestOverlapsSpreadCells(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(80))
+	bxs := []Box{}
+
+	// 100 boxes centered near (10, 10) and one centered at each other corner of [10, 90]², all containing (50, 50)
+	for i := 0; i < 100; i++ {
+
+		x, y := 10+rng.Float64(), 10+rng.Float64()
+		bxs = append(bxs, &testBox{[]float64{x - 45, y - 45}, []float64{x + 45, y + 45}})
+
+	}
+
+	for _, c := range [][2]float64{{90, 10}, {10, 90}, {90, 90}} {
+		bxs = append(bxs, &testBox{[]float64{c[0] - 45, c[1] - 45}, []float64{c[0] + 45, c[1] + 45}})
+	}
+
+	tree := NewBOXTree(bxs)
+	vals := []float64{50, 50}
+
+	for _, max := range []int{4, 9, 20} {
+
+		res := tree.OverlapsSpread(vals, max)
+
+		if len(res) != max {
+			t.Fatalf("OverlapsSpread(%v, %d): %d results", vals, max, len(res))
+		}
+
+		// every corner is represented even though the cluster alone could fill the cap
+		got := map[int]bool{}
+
+		for _, idx := range res {
+
+			if l, u := bxs[idx].Limits(); !within(l, u, vals) {
+				t.Fatalf("OverlapsSpread(%v, %d): box %d does not overlap", vals, max, idx)
+			}
+
+			got[idx] = true
+
+		}
+
+		if len(got) != max || !got[100] || !got[101] || !got[102] {
+			t.Fatalf("OverlapsSpread(%v, %d) = %v, want all of 100, 101, 102 and no duplicates", vals, max, res)
+		}
+
+	}
+
+	if res := tree.OverlapsSpread(vals, 200); !equalInts(sorted(res), bruteOverlaps(bxs, vals)) {
+		t.Fatalf("OverlapsSpread above the match count = %v, want all matches", res)
+	}
+
+	if res := tree.OverlapsSpread(vals, 0); res == nil || len(res) != 0 {
+		t.Fatalf("OverlapsSpread(%v, 0) = %v, want empty", vals, res)
+	}
+
+}
+
+func TestOverlapsModeBoundaries(t *testing.T) {
+
+	// a 2x2 tiling of unit boxes, numbered row by row from the origin
+	tiles := NewBOXTree([]Box{
+		&testBox{[]float64{0, 0}, []float64{1, 1}},
+		&testBox{[]float64{1, 0}, []float64{2, 1}},
+		&testBox{[]float64{0, 1}, []float64{1, 2}},
+		&testBox{[]float64{1, 1}, []float64{2, 2}},
+	})
+
+	for _, tc := range []struct {
+		vals                        []float64
+		inclusive, strict, halfOpen []int
+	}{
+		{[]float64{0.5, 0.5}, []int{0}, []int{0}, []int{0}},
+		{[]float64{1, 0.5}, []int{0, 1}, []int{}, []int{1}},
+		{[]float64{1.5, 1}, []int{1, 3}, []int{}, []int{3}},
+		{[]float64{1, 1}, []int{0, 1, 2, 3}, []int{}, []int{3}},
+		{[]float64{0, 0}, []int{0}, []int{}, []int{0}},
+		{[]float64{2, 0.5}, []int{1}, []int{}, []int{}},
+		{[]float64{2, 2}, []int{3}, []int{}, []int{}},
+		{[]float64{3, 3}, []int{}, []int{}, []int{}},
+	} {
+
+		for _, m := range []struct {
+			mode SemanticMode
+			want []int
+		}{
+			{ModeInclusive, tc.inclusive},
+			{ModeStrict, tc.strict},
+			{ModeHalfOpen, tc.halfOpen},
+			{SemanticMode(99), []int{}},
+		} {
+
+			if got := sorted(tiles.OverlapsMode(tc.vals, m.mode)); !equalInts(got, m.want) {
+				t.Fatalf("OverlapsMode(%v, %d) = %v, want %v", tc.vals, m.mode, got, m.want)
+			}
+
+		}
+
+	}
+
+	// the default mode reproduces Overlaps exactly, order included
+	rng := rand.New(rand.NewSource(81))
+	tree := NewBOXTree(randomBoxes(rng, 1000, 100, 10))
+
+	for q := 0; q < 100; q++ {
+
+		vals := []float64{rng.Float64() * 110, rng.Float64() * 110}
+
+		if got, want := tree.OverlapsMode(vals, ModeInclusive), tree.Overlaps(vals); !equalInts(got, want) {
+			t.Fatalf("OverlapsMode(%v, ModeInclusive) = %v, want %v", vals, got, want)
+		}
+
+	}
+
+}
+
+func TestOverlapsWithDepthConsistent(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(85))
+	bxs := randomBoxes(rng, 1000, 100, 20)
+	tree := NewBOXTree(bxs)
+
+	for q := 0; q < 300; q++ {
+
+		vals := []float64{rng.Float64() * 120, rng.Float64() * 120}
+		indices, depth := tree.OverlapsWithDepth(vals)
+
+		if depth != len(indices) {
+			t.Fatalf("OverlapsWithDepth(%v): depth %d for %d indices", vals, depth, len(indices))
+		}
+
+		if want := tree.Overlaps(vals); !equalInts(indices, want) {
+			t.Fatalf("OverlapsWithDepth(%v) = %v, want %v", vals, indices, want)
+		}
+
+		if want := len(bruteOverlaps(bxs, vals)); depth != want {
+			t.Fatalf("OverlapsWithDepth(%v): depth %d, want %d", vals, depth, want)
+		}
+
+	}
+
+	if indices, depth := NewBOXTree(nil).OverlapsWithDepth([]float64{0, 0}); len(indices) != 0 || depth != 0 {
+		t.Fatalf("OverlapsWithDepth on empty tree = %v, %d, want none, 0", indices, depth)
+	}
+
+}
@@ -0,0 +1,119 @@
+// MIT License
+//
+// Copyright (c) 2022 geozelot (André Siefken)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package boxtree
+
+import (
+	"sync"
+)
+
+// QueryPool is the allocation free query front end for concurrent use;
+// hands out reusable Query buffers bound to a single shared tree, which itself stays read-only.
+type QueryPool struct {
+	boT  *BOXTree
+	pool sync.Pool
+}
+
+// Query is a reusable query, holding options and result buffers across calls; not safe for concurrent use itself.
+//
+// Obtained from a QueryPool, it is bound to the pool's tree for Overlaps; created directly, its exported fields configure BOXTree.Do.
+type Query struct {
+	// Vals are the values to match.
+	Vals []float64
+
+	// Mode selects the overlap semantics, ModeInclusive by default.
+	Mode SemanticMode
+
+	// Limit caps the number of results if above 0, stopping the traversal once reached.
+	Limit int
+
+	// Filter, if set, drops results for which it returns false, before Limit applies.
+	Filter func(idx int) bool
+
+	// Dst is the result buffer, reused (and overwritten) by every call of BOXTree.Do.
+	Dst []int
+
+	boT *BOXTree
+	buf buffer
+}
+
+// NewQueryPool is the QueryPool initialization function; binds a new pool to the given tree.
+func NewQueryPool(boT *BOXTree) *QueryPool {
+
+	qP := QueryPool{boT: boT}
+	qP.pool.New = func() interface{} {
+		return &Query{boT: boT, buf: buffer{res: make([]int, 0, 64)}}
+	}
+
+	return &qP
+
+}
+
+// Get takes a Query from the pool, creating one if none is available; return it with Put when done.
+func (qP *QueryPool) Get() *Query {
+
+	return qP.pool.Get().(*Query)
+
+}
+
+// Put returns the given Query to the pool; neither it nor any result obtained from it may be used afterwards.
+func (qP *QueryPool) Put(q *Query) {
+
+	qP.pool.Put(q)
+
+}
+
+// Do is the scoped query function; runs Overlaps on a pooled Query and passes the result to fn, which must not retain it.
+func (qP *QueryPool) Do(vals []float64, fn func(res []int)) {
+
+	q := qP.Get()
+	fn(q.Overlaps(vals))
+	qP.Put(q)
+
+}
+
+// Overlaps is the buffered variant of BOXTree.Overlaps;
+// collects overlapping boxes into the Query's own buffer, which is reused, and thus overwritten, by the next call.
+//
+// After the buffer has grown to the largest result size, queries do not allocate, except on trees built by NewBOXTreeWrapped;
+// Tracers are not notified of query start or end.
+// A Query created directly is not bound to any tree and returns nil; use BOXTree.Do for those.
+func (q *Query) Overlaps(vals []float64) []int {
+
+	if q.boT == nil {
+		return nil
+	}
+
+	res := q.buf.res[:0]
+
+	q.boT.walk(&q.buf, vals, within, func(cn int) bool {
+
+		res = append(res, q.boT.idxs[cn])
+		return true
+
+	})
+
+	q.buf.res = res
+
+	return res
+
+}
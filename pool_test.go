@@ -0,0 +1,106 @@
+package boxtree
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func TestQueryPoolOverlaps(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(34))
+	bxs := randomBoxes(rng, 1000, 100, 10)
+	qP := NewQueryPool(NewBOXTree(bxs))
+
+	for i := 0; i < 100; i++ {
+
+		vals := []float64{rng.Float64() * 110, rng.Float64() * 110}
+
+		qP.Do(vals, func(res []int) {
+
+			if got, want := sorted(res), bruteOverlaps(bxs, vals); !equalInts(got, want) {
+				t.Fatalf("Overlaps(%v) = %v, want %v", vals, got, want)
+			}
+
+		})
+
+	}
+
+}
+
+func TestUnboundQuery(t *testing.T) {
+
+	if res := (&Query{}).Overlaps([]float64{0, 0}); res != nil {
+		t.Fatalf("unbound Query: Overlaps = %v, want nil", res)
+	}
+
+}
+
+func TestQueryPoolZeroAllocs(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(52))
+	qP := NewQueryPool(NewBOXTree(randomBoxes(rng, 10000, 100, 10)))
+	vals := []float64{50, 50}
+
+	// the first call grows the result buffer, later ones reuse it
+	qP.Do(vals, func([]int) {})
+
+	q := qP.Get()
+	q.Overlaps(vals)
+
+	if n := testing.AllocsPerRun(100, func() { q.Overlaps(vals) }); n != 0 {
+		t.Fatalf("Query.Overlaps allocates %v times per call after warmup, want 0", n)
+	}
+
+	qP.Put(q)
+
+}
+
+func BenchmarkQueryPool32(b *testing.B) {
+
+	rng := rand.New(rand.NewSource(3))
+	qP := NewQueryPool(NewBOXTree(randomBoxes(rng, 100000, 1000, 10)))
+
+	qs := make([][]float64, 1024)
+
+	for i := range qs {
+		qs[i] = []float64{rng.Float64() * 1000, rng.Float64() * 1000}
+	}
+
+	// queries split over 32 goroutines, each holding one pooled Query; a warmup run grows the pooled result buffers before measuring
+	run := func(n int) {
+
+		var wg sync.WaitGroup
+
+		for g := 0; g < 32; g++ {
+
+			wg.Add(1)
+
+			go func(g int) {
+
+				defer wg.Done()
+
+				q := qP.Get()
+
+				for i := g; i < n; i += 32 {
+					q.Overlaps(qs[i%len(qs)])
+				}
+
+				qP.Put(q)
+
+			}(g)
+
+		}
+
+		wg.Wait()
+
+	}
+
+	run(32 * len(qs))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	run(b.N)
+
+}
@@ -0,0 +1,105 @@
+// MIT License
+//
+// Copyright (c) 2022 geozelot (André Siefken)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package boxtree
+
+// progressChunk is the range size below which builds with progress reporting sort and augment without reporting.
+const progressChunk = 1 << 14
+
+// progress is the internal build progress counter; reports placed and augmented nodes to fn, if set.
+type progress struct {
+	fn          func(done, total int)
+	done, total int
+}
+
+// NewBOXTreeProgress is the progress reporting initialization function;
+// creates the tree from the given Slice of Box like NewBOXTree, calling onProgress periodically during the build.
+//
+// Progress counts nodes placed by the sort and nodes augmented, so total is twice the number of stored boxes;
+// it is reported about every 16k nodes, with a last call where done equals total, and not at all for empty input.
+// Calls happen on the building goroutine; onProgress should return quickly, as the build waits for it.
+func NewBOXTreeProgress(bxs []Box, onProgress func(done, total int), opts ...Option) *BOXTree {
+
+	boT := BOXTree{progress: onProgress}
+
+	for _, opt := range opts {
+		opt(&boT)
+	}
+
+	boT.buildTree(bxs)
+
+	return &boT
+
+}
+
+// sort is the progress reporting variant of the sort utility function.
+func (pr *progress) sort(lmts [][]float64, idxs []int, ax int, st int, lf int) {
+
+	if pr.fn == nil || len(idxs) <= progressChunk || len(idxs) <= lf {
+
+		sort(lmts, idxs, ax, st, lf)
+		pr.add(len(idxs))
+
+		return
+
+	}
+
+	k := median(lmts, idxs, ax)
+
+	pr.sort(lmts[:2*k], idxs[:k], (ax+st)%2, st, lf)
+	pr.sort(lmts[2*k+2:], idxs[k+1:], (ax+st)%2, st, lf)
+	pr.add(1)
+
+}
+
+// augment is the progress reporting variant of the augment utility function.
+func (pr *progress) augment(lmts [][]float64, ags []float64, idxs []int, ax int, st int, lf int) {
+
+	if pr.fn == nil || len(idxs) <= progressChunk || len(idxs) <= lf {
+
+		augment(lmts, ags, idxs, ax, st, lf)
+		pr.add(len(idxs))
+
+		return
+
+	}
+
+	r := len(idxs) >> 1
+	ags[2*r], ags[2*r+1] = bounds(lmts, len(idxs), ax)
+
+	pr.augment(lmts[:2*r], ags[:2*r], idxs[:r], (ax+st)%2, st, lf)
+	pr.augment(lmts[2*r+2:], ags[2*r+2:], idxs[r+1:], (ax+st)%2, st, lf)
+	pr.add(1)
+
+}
+
+// add counts n more nodes as done, reporting to fn if set.
+func (pr *progress) add(n int) {
+
+	if pr.fn == nil || n == 0 {
+		return
+	}
+
+	pr.done += n
+	pr.fn(pr.done, pr.total)
+
+}
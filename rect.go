@@ -0,0 +1,60 @@
+// MIT License
+//
+// Copyright (c) 2022 geozelot (André Siefken)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package boxtree
+
+import (
+	"fmt"
+)
+
+// RectBox is the built-in rectangle implementation of Box, holding the limits in order minimum x, minimum y, maximum x, maximum y.
+//
+// Limits() returns Slices of the array itself, so the tree references the RectBox, and in-place edits are seen by UpdateLimits or Reaugment.
+type RectBox [4]float64
+
+// NewRect is the rectangle constructor; returns a Box with the given limits, without any validation.
+func NewRect(minx, miny, maxx, maxy float64) Box {
+
+	return &RectBox{minx, miny, maxx, maxy}
+
+}
+
+// NewRectChecked is the validating rectangle constructor;
+// returns a Box with the given limits, or an error (matching ErrNonFinite or ErrInvalidBox) if they are not finite or not ordered.
+func NewRectChecked(minx, miny, maxx, maxy float64) (Box, error) {
+
+	rb := &RectBox{minx, miny, maxx, maxy}
+
+	if err := checkBox(rb.Limits()); err != nil {
+		return nil, fmt.Errorf("%w: rect [%g %g %g %g]", err, minx, miny, maxx, maxy)
+	}
+
+	return rb, nil
+
+}
+
+// Limits accesses the box limits; implements Box.
+func (rb *RectBox) Limits() (Lower, Upper []float64) {
+
+	return rb[0:2:2], rb[2:4:4]
+
+}
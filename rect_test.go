@@ -0,0 +1,82 @@
+package boxtree
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestNewRectChecked(t *testing.T) {
+
+	for _, tc := range []struct {
+		rect [4]float64
+		want error
+	}{
+		{[4]float64{0, 0, 1, 1}, nil},
+		{[4]float64{-2, 3, -2, 3}, nil},
+		{[4]float64{1, 0, 0, 1}, ErrInvalidBox},
+		{[4]float64{0, 1, 1, 0}, ErrInvalidBox},
+		{[4]float64{math.NaN(), 0, 1, 1}, ErrNonFinite},
+		{[4]float64{0, 0, math.Inf(1), 1}, ErrNonFinite},
+	} {
+
+		bx, err := NewRectChecked(tc.rect[0], tc.rect[1], tc.rect[2], tc.rect[3])
+
+		if tc.want == nil {
+
+			if err != nil {
+				t.Fatalf("NewRectChecked(%v): %v", tc.rect, err)
+			}
+
+			if l, u := bx.Limits(); l[0] != tc.rect[0] || l[1] != tc.rect[1] || u[0] != tc.rect[2] || u[1] != tc.rect[3] {
+				t.Fatalf("NewRectChecked(%v) limits = %v %v", tc.rect, l, u)
+			}
+
+			continue
+
+		}
+
+		if !errors.Is(err, tc.want) || bx != nil {
+			t.Fatalf("NewRectChecked(%v) = %v, %v, want nil, %v", tc.rect, bx, err, tc.want)
+		}
+
+	}
+
+}
+
+func TestRectBoxMatchesBruteForce(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(68))
+	tbs := randomBoxes(rng, 1000, 100, 10)
+	rbs := make([]Box, len(tbs))
+
+	for i, tb := range tbs {
+
+		l, u := tb.Limits()
+		rbs[i] = NewRect(l[0], l[1], u[0], u[1])
+
+	}
+
+	tree := NewBOXTree(rbs)
+
+	for q := 0; q < 300; q++ {
+
+		vals := []float64{rng.Float64() * 110, rng.Float64() * 110}
+
+		if got, want := sorted(tree.Overlaps(vals)), bruteOverlaps(tbs, vals); !equalInts(got, want) {
+			t.Fatalf("Overlaps(%v) = %v, want %v", vals, got, want)
+		}
+
+	}
+
+	// the limits are Slices of the array itself, so edits followed by Reaugment take effect
+	rb := rbs[0].(*RectBox)
+	rb[2], rb[3] = 200, 200
+	tree.Reaugment()
+
+	if res := tree.Overlaps([]float64{150, 150}); !equalInts(res, []int{0}) {
+		t.Fatalf("Overlaps after editing the RectBox = %v, want [0]", res)
+	}
+
+}
@@ -0,0 +1,91 @@
+// MIT License
+//
+// Copyright (c) 2022 geozelot (André Siefken)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package boxtree
+
+import (
+	gosort "sort"
+)
+
+// SortByAxis is the exported tree ordering function;
+// permutes the given parallel Slices of lower limits, upper limits and indices into the layout used by BOXTree, starting on axis ax.
+//
+// The ordering is a QuickSelect-style partition: the median by lower limit is placed on the midpoint of each range,
+// with smaller or equal values before and greater or equal values after it, alternating the axis per level.
+// It is not stable; boxes with equal lower limits may end up in any relative order.
+func SortByAxis(lowers, uppers [][]float64, idxs []int, ax int) {
+
+	lmts := make([][]float64, 2*len(idxs))
+
+	for i := range idxs {
+		lmts[2*i], lmts[2*i+1] = lowers[i], uppers[i]
+	}
+
+	sort(lmts, idxs, ax, 1, 0)
+
+	for i := range idxs {
+		lowers[i], uppers[i] = lmts[2*i], lmts[2*i+1]
+	}
+
+}
+
+// SortByAxisStable is the stable variant of SortByAxis;
+// produces the same layout invariant, but keeps boxes with equal lower limits in their given relative order on every level.
+//
+// It is deterministic, at the cost of a full sort.Stable per level, O(n log³ n) instead of expected O(n log n) time.
+func SortByAxisStable(lowers, uppers [][]float64, idxs []int, ax int) {
+
+	if len(idxs) < 2 {
+		return
+	}
+
+	gosort.Stable(byAxis{lowers, uppers, idxs, ax})
+
+	k := len(idxs) >> 1
+
+	SortByAxisStable(lowers[:k], uppers[:k], idxs[:k], (ax+1)%2)
+	SortByAxisStable(lowers[k+1:], uppers[k+1:], idxs[k+1:], (ax+1)%2)
+
+}
+
+// byAxis is the internal sort.Interface over parallel Slices, ordering by lower limit on a single axis.
+type byAxis struct {
+	lowers, uppers [][]float64
+	idxs           []int
+	ax             int
+}
+
+func (ba byAxis) Len() int {
+	return len(ba.idxs)
+}
+
+func (ba byAxis) Less(i, j int) bool {
+	return ba.lowers[i][ba.ax] < ba.lowers[j][ba.ax]
+}
+
+func (ba byAxis) Swap(i, j int) {
+
+	ba.lowers[i], ba.lowers[j] = ba.lowers[j], ba.lowers[i]
+	ba.uppers[i], ba.uppers[j] = ba.uppers[j], ba.uppers[i]
+	ba.idxs[i], ba.idxs[j] = ba.idxs[j], ba.idxs[i]
+
+}
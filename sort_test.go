@@ -0,0 +1,116 @@
+package boxtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// checkLayout verifies the partition invariant of SortByAxis on every level: no lower limit on the split axis
+// left of the midpoint exceeds, and none right of it falls below, that of the midpoint.
+func checkLayout(t *testing.T, lowers [][]float64, lb, rb, ax int) {
+
+	t.Helper()
+
+	if lb >= rb {
+		return
+	}
+
+	cn := (lb + rb + 1) / 2
+	m := lowers[cn][ax]
+
+	for i := lb; i <= rb; i++ {
+
+		if (i < cn && lowers[i][ax] > m) || (i > cn && lowers[i][ax] < m) {
+			t.Fatalf("range [%d, %d] axis %d: lower %v at %d on the wrong side of midpoint %d (%v)", lb, rb, ax, lowers[i][ax], i, cn, m)
+		}
+
+	}
+
+	checkLayout(t, lowers, lb, cn-1, (ax+1)%2)
+	checkLayout(t, lowers, cn+1, rb, (ax+1)%2)
+
+}
+
+// parallel builds parallel Slices from random boxes with coarse, frequently equal limits.
+func parallel(rng *rand.Rand, n int) (lowers, uppers [][]float64, idxs []int) {
+
+	lowers, uppers, idxs = make([][]float64, n), make([][]float64, n), make([]int, n)
+
+	for i := range idxs {
+
+		x, y := float64(rng.Intn(20)), float64(rng.Intn(20))
+		lowers[i], uppers[i], idxs[i] = []float64{x, y}, []float64{x + 1, y + 1}, i
+
+	}
+
+	return lowers, uppers, idxs
+
+}
+
+func TestSortByAxisLayout(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(23))
+
+	for _, n := range []int{0, 1, 2, 3, 7, 100, 1000} {
+
+		for _, ax := range []int{0, 1} {
+
+			for _, sortFn := range []func([][]float64, [][]float64, []int, int){SortByAxis, SortByAxisStable} {
+
+				lowers, uppers, idxs := parallel(rng, n)
+				orig := append([][]float64(nil), lowers...)
+
+				sortFn(lowers, uppers, idxs, ax)
+				checkLayout(t, lowers, 0, n-1, ax)
+
+				seen := make([]bool, n)
+
+				for i, idx := range idxs {
+
+					if seen[idx] || &orig[idx][0] != &lowers[i][0] || uppers[i][0] != lowers[i][0]+1 {
+						t.Fatalf("n %d: Slices no longer parallel at %d", n, i)
+					}
+
+					seen[idx] = true
+
+				}
+
+			}
+
+		}
+
+	}
+
+}
+
+func TestSortByAxisStableIsStable(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(24))
+	lowers, uppers, idxs := parallel(rng, 500)
+
+	for i := range lowers {
+		lowers[i][0], lowers[i][1] = 3, 5
+	}
+
+	// with all lower limits equal, every level must keep the given order
+	SortByAxisStable(lowers, uppers, idxs, 0)
+
+	for i, idx := range idxs {
+
+		if idx != i {
+			t.Fatalf("equal limits reordered: idxs[%d] = %d", i, idx)
+		}
+
+	}
+
+	l1, u1, i1 := parallel(rand.New(rand.NewSource(25)), 500)
+	l2, u2, i2 := parallel(rand.New(rand.NewSource(25)), 500)
+
+	SortByAxisStable(l1, u1, i1, 1)
+	SortByAxisStable(l2, u2, i2, 1)
+
+	if !equalInts(i1, i2) {
+		t.Fatal("SortByAxisStable is not deterministic")
+	}
+
+}
@@ -0,0 +1,57 @@
+// MIT License
+//
+// Copyright (c) 2022 geozelot (André Siefken)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package boxtree
+
+// Stats is the structural summary returned by Stats();
+// holds the number of stored boxes, the number of tree levels (a leaf bucket counting as one, see WithLeafSize) and the axis detected as flat at build time (-1 if none).
+//
+// On a flat axis all boxes share identical limits, so the tree is leveled on the other axis only.
+type Stats struct {
+	Boxes    int
+	Height   int
+	FlatAxis int
+}
+
+// Stats reports the structural summary of the tree.
+func (boT *BOXTree) Stats() Stats {
+
+	st := Stats{Boxes: len(boT.idxs), FlatAxis: -1}
+
+	// the left half of each range is the larger one, so the height follows its size down to the first bucket
+	for m := len(boT.idxs); m > 0; m >>= 1 {
+
+		st.Height++
+
+		if m <= boT.leaf {
+			break
+		}
+
+	}
+
+	if boT.flat {
+		st.FlatAxis = (boT.ax0 + 1) % 2
+	}
+
+	return st
+
+}
@@ -0,0 +1,111 @@
+package boxtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// depth returns the number of levels visited by the traversal engine, counting a leaf bucket as one.
+func depth(boT *BOXTree, lb, rb int) int {
+
+	if lb > rb {
+		return 0
+	}
+
+	if rb-lb < boT.leaf {
+		return 1
+	}
+
+	cn := (lb + rb + 1) / 2
+	l, r := depth(boT, lb, cn-1), depth(boT, cn+1, rb)
+
+	if r > l {
+		l = r
+	}
+
+	return 1 + l
+
+}
+
+func TestStatsHeight(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(29))
+
+	for _, n := range []int{0, 1, 2, 3, 16, 17, 100, 1000} {
+
+		for _, lf := range []int{0, 1, 4, 16, 2000} {
+
+			tree := NewBOXTree(randomBoxes(rng, n, 100, 10), WithLeafSize(lf))
+
+			if got, want := tree.Stats().Height, depth(tree, 0, n-1); got != want {
+				t.Fatalf("n %d, leaf %d: Height = %d, want %d", n, lf, got, want)
+			}
+
+		}
+
+	}
+
+	if h := NewBOXTree(randomBoxes(rng, 1000, 100, 10), WithLeafSize(16)).Stats().Height; h != 7 {
+		t.Fatalf("1000 boxes, leaf 16: Height = %d, want 7", h)
+	}
+
+}
+
+func TestFlatAxisDetection(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(61))
+
+	for _, fa := range []int{0, 1} {
+
+		// all boxes share the limits {2, 5} on the flat axis
+		bxs := randomBoxes(rng, 1000, 100, 10)
+
+		for _, bx := range bxs {
+
+			l, u := bx.Limits()
+			l[fa], u[fa] = 2, 5
+
+		}
+
+		for _, lf := range []int{0, 8} {
+
+			tree := NewBOXTree(bxs, WithLeafSize(lf))
+
+			if got := tree.Stats().FlatAxis; got != fa {
+				t.Fatalf("leaf %d: FlatAxis = %d, want %d", lf, got, fa)
+			}
+
+			if err := tree.Validate(); err != nil {
+				t.Fatalf("leaf %d: %v", lf, err)
+			}
+
+			for q := 0; q < 300; q++ {
+
+				vals := []float64{rng.Float64() * 110, rng.Float64() * 110}
+				vals[fa] = rng.Float64() * 7
+
+				if got, want := sorted(tree.Overlaps(vals)), bruteOverlaps(bxs, vals); !equalInts(got, want) {
+					t.Fatalf("flat axis %d, leaf %d: Overlaps(%v) = %v, want %v", fa, lf, vals, got, want)
+				}
+
+			}
+
+		}
+
+	}
+
+	// one box off the shared limits keeps both axes
+	bxs := randomBoxes(rng, 100, 100, 10)
+
+	for _, bx := range bxs[1:] {
+
+		l, u := bx.Limits()
+		l[1], u[1] = 2, 5
+
+	}
+
+	if got := NewBOXTree(bxs).Stats().FlatAxis; got != -1 {
+		t.Fatalf("FlatAxis with one differing box = %d, want -1", got)
+	}
+
+}
@@ -0,0 +1,60 @@
+// MIT License
+//
+// Copyright (c) 2022 geozelot (André Siefken)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package boxtree
+
+// NewBOXTreeWithStore is the reference retaining initialization function;
+// creates the tree from the given Slice of Box like NewBOXTree, additionally keeping a copy of the Slice to return
+// matched Box values directly.
+//
+// This trades one interface value per box, and keeping all boxes reachable, for not having to manage indices.
+func NewBOXTreeWithStore(bxs []Box, opts ...Option) *BOXTree {
+
+	boT := NewBOXTree(bxs, opts...)
+	boT.store = append([]Box(nil), bxs...)
+
+	return boT
+
+}
+
+// OverlapsBoxRefs is the reference variant of Overlaps;
+// traverses the tree and collects the stored Box values that overlap with the given values.
+//
+// Returns nil for trees built without NewBOXTreeWithStore.
+func (boT *BOXTree) OverlapsBoxRefs(vals []float64) []Box {
+
+	if boT.store == nil {
+		return nil
+	}
+
+	res := []Box{}
+
+	boT.traverse(vals, func(cn int) bool {
+
+		res = append(res, boT.store[boT.idxs[cn]])
+		return true
+
+	})
+
+	return res
+
+}
@@ -0,0 +1,59 @@
+package boxtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestOverlapsBoxRefs(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(62))
+	bxs := randomBoxes(rng, 1000, 100, 10)
+	tree := NewBOXTreeWithStore(bxs)
+
+	// the tree keeps its own copy of the Slice
+	bxs[0] = &testBox{[]float64{-10, -10}, []float64{-9, -9}}
+
+	if res := tree.OverlapsBoxRefs([]float64{-9.5, -9.5}); len(res) != 0 {
+		t.Fatalf("OverlapsBoxRefs found a box replaced in the input Slice after the build: %v", res)
+	}
+
+	bxs = tree.store
+
+	for q := 0; q < 300; q++ {
+
+		vals := []float64{rng.Float64() * 110, rng.Float64() * 110}
+		res := tree.OverlapsBoxRefs(vals)
+		want := bruteOverlaps(bxs, vals)
+
+		if len(res) != len(want) {
+			t.Fatalf("OverlapsBoxRefs(%v): %d boxes, want %d", vals, len(res), len(want))
+		}
+
+		seen := map[Box]bool{}
+
+		for _, bx := range res {
+
+			if l, u := bx.Limits(); !within(l, u, vals) {
+				t.Fatalf("OverlapsBoxRefs(%v): box %v %v does not overlap", vals, l, u)
+			}
+
+			seen[bx] = true
+
+		}
+
+		for _, idx := range want {
+
+			if !seen[bxs[idx]] {
+				t.Fatalf("OverlapsBoxRefs(%v): box %d missing", vals, idx)
+			}
+
+		}
+
+	}
+
+	if res := NewBOXTree(bxs).OverlapsBoxRefs([]float64{50, 50}); res != nil {
+		t.Fatalf("OverlapsBoxRefs without store = %v, want nil", res)
+	}
+
+}
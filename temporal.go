@@ -0,0 +1,98 @@
+// MIT License
+//
+// Copyright (c) 2022 geozelot (André Siefken)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package boxtree
+
+import (
+	gosort "sort"
+)
+
+// TemporalIndex is the time-indexed snapshot set;
+// holds trees keyed by timestamp, each representing the spatial state from its timestamp on until the next one.
+//
+// Adding snapshots is not safe for concurrent use with queries.
+type TemporalIndex struct {
+	snps map[int64]*BOXTree
+	tms  []int64
+}
+
+// NewTemporalIndex is the TemporalIndex initialization function; creates an index from the given snapshots (may be nil).
+func NewTemporalIndex(snapshots map[int64]*BOXTree) *TemporalIndex {
+
+	tI := TemporalIndex{snps: map[int64]*BOXTree{}}
+
+	for t, boT := range snapshots {
+		tI.Add(t, boT)
+	}
+
+	return &tI
+
+}
+
+// Add registers the tree as the snapshot at timestamp t, replacing any snapshot previously registered at t.
+func (tI *TemporalIndex) Add(t int64, boT *BOXTree) {
+
+	if _, ok := tI.snps[t]; !ok {
+
+		i := gosort.Search(len(tI.tms), func(i int) bool {
+			return tI.tms[i] >= t
+		})
+
+		tI.tms = append(tI.tms, 0)
+		copy(tI.tms[i+1:], tI.tms[i:])
+		tI.tms[i] = t
+
+	}
+
+	tI.snps[t] = boT
+
+}
+
+// At selects the snapshot valid at timestamp t, i.e. the one with the latest timestamp at or before t;
+// returns nil if t precedes all snapshots.
+func (tI *TemporalIndex) At(t int64) *BOXTree {
+
+	i := gosort.Search(len(tI.tms), func(i int) bool {
+		return tI.tms[i] > t
+	})
+
+	if i == 0 {
+		return nil
+	}
+
+	return tI.snps[tI.tms[i-1]]
+
+}
+
+// OverlapsAt is the temporal variant of Overlaps;
+// queries the snapshot valid at timestamp t (see At), returning no matches if t precedes all snapshots.
+func (tI *TemporalIndex) OverlapsAt(t int64, vals []float64) []int {
+
+	boT := tI.At(t)
+
+	if boT == nil {
+		return []int{}
+	}
+
+	return boT.Overlaps(vals)
+
+}
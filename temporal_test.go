@@ -0,0 +1,84 @@
+package boxtree
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTemporalIndexBoundaries(t *testing.T) {
+
+	// snapshot n holds n+1 boxes on the line x = n, telling apart both its size and its matches
+	snap := func(n int) *BOXTree {
+
+		bxs := make([]Box, n+1)
+
+		for i := range bxs {
+			bxs[i] = &testBox{[]float64{float64(n), 0}, []float64{float64(n), 1}}
+		}
+
+		return NewBOXTree(bxs)
+
+	}
+
+	tI := NewTemporalIndex(map[int64]*BOXTree{100: snap(1), -50: snap(0)})
+	tI.Add(200, snap(2))
+	tI.Add(math.MaxInt64, snap(3))
+
+	for _, tc := range []struct {
+		t    int64
+		want int
+	}{
+		{math.MinInt64, -1},
+		{-51, -1},
+		{-50, 0},
+		{99, 0},
+		{100, 1},
+		{101, 1},
+		{199, 1},
+		{200, 2},
+		{math.MaxInt64 - 1, 2},
+		{math.MaxInt64, 3},
+	} {
+
+		got := -1
+
+		if boT := tI.At(tc.t); boT != nil {
+			got = boT.Len() - 1
+		}
+
+		if got != tc.want {
+			t.Fatalf("At(%d) = snapshot %d, want %d", tc.t, got, tc.want)
+		}
+
+		for n := 0; n < 4; n++ {
+
+			want := 0
+
+			if n == tc.want {
+				want = n + 1
+			}
+
+			if res := tI.OverlapsAt(tc.t, []float64{float64(n), 0.5}); len(res) != want {
+				t.Fatalf("OverlapsAt(%d) on line %d = %v, want %d matches", tc.t, n, res, want)
+			}
+
+		}
+
+	}
+
+	// replacing a snapshot at an existing timestamp keeps a single entry
+	tI.Add(100, snap(4))
+
+	if got := tI.At(150).Len(); got != 5 {
+		t.Fatalf("At(150) after replacement: %d boxes, want 5", got)
+	}
+
+	if got := len(tI.tms); got != 4 {
+		t.Fatalf("%d timestamps after replacement, want 4", got)
+	}
+
+	if res := NewTemporalIndex(nil).OverlapsAt(0, []float64{0, 0}); res == nil || len(res) != 0 {
+		t.Fatalf("empty index OverlapsAt = %v, want empty", res)
+	}
+
+}
@@ -0,0 +1,194 @@
+// MIT License
+//
+// Copyright (c) 2022 geozelot (André Siefken)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package boxtree
+
+import (
+	"math"
+)
+
+// Tracer is the optional query observer interface set via SetTracer(); notified of query progress for profiling.
+//
+// OnQueryStart and OnQueryEnd bracket each Overlaps call, the latter receiving the number of results.
+// OnNodeVisit receives the position of every node visited by a point traversal of the tree, including those of other query variants.
+// Implementations must be safe for concurrent use if the tree is queried concurrently.
+type Tracer interface {
+	OnQueryStart(vals []float64)
+	OnNodeVisit(node int)
+	OnQueryEnd(results int)
+}
+
+// SetTracer attaches the given Tracer to the tree; nil detaches it, leaving queries without any tracing overhead.
+func (boT *BOXTree) SetTracer(t Tracer) {
+
+	boT.tracer = t
+
+}
+
+// TraceDecision is the bit set of decisions taken at a node visited by OverlapsTrace.
+type TraceDecision uint8
+
+// Decisions recorded by OverlapsTrace; TraceLeft and TraceRight mark descending into the respective child range,
+// TracePrune marks a non-empty child range skipped by the augmented or split limits, or a bucket skipped as a whole,
+// TraceBucket marks a bucket scanned linearly (see WithLeafSize) and TraceHit a box overlapping the values.
+const (
+	TraceLeft TraceDecision = 1 << iota
+	TraceRight
+	TracePrune
+	TraceBucket
+	TraceHit
+)
+
+// TraceStep is a single node visit recorded by OverlapsTrace.
+//
+// Pos is the node position (see Positions), Index the box index, Axis the split axis of the node's level
+// and Max the augmented maximum upper limit of its subtree on that axis. Boxes hit within a scanned bucket get a step of their own.
+type TraceStep struct {
+	Pos      int
+	Index    int
+	Axis     int
+	Max      float64
+	Decision TraceDecision
+}
+
+// OverlapsTrace is the debugging variant of Overlaps;
+// traverses the tree like Overlaps does, recording every visited node and the decisions taken there in visiting order.
+//
+// The boxes of all steps with TraceHit are exactly those returned by Overlaps on trees not built by NewBOXTreeWrapped. Intended as a developer tool;
+// it allocates per step and should be kept out of the hot path. A Tracer set on the tree is notified of the node visits as usual.
+func (boT *BOXTree) OverlapsTrace(vals []float64) []TraceStep {
+
+	res := []TraceStep{}
+	buf := buffers.Get().(*buffer)
+
+	buf.hook = func(lb, rb, pos, ax int, dec TraceDecision) {
+
+		cn := int(math.Ceil(float64(lb+rb) / 2.0))
+		res = append(res, TraceStep{Pos: pos, Index: boT.idxs[pos], Axis: ax, Max: boT.ags[2*cn], Decision: dec})
+
+	}
+
+	boT.walk(buf, vals, within, func(int) bool { return true })
+
+	buf.hook = nil
+	buffers.Put(buf)
+
+	return res
+
+}
+
+// decide is an internal utility function, combining the decisions taken at node cn of the range [lb, rb];
+// a half not descended into counts as pruned only if it is non-empty.
+func decide(lb, rb, cn int, left, right, hit bool) TraceDecision {
+
+	var dec TraceDecision
+
+	if left {
+		dec |= TraceLeft
+	} else if lb < cn {
+		dec |= TracePrune
+	}
+
+	if right {
+		dec |= TraceRight
+	} else if cn < rb {
+		dec |= TracePrune
+	}
+
+	if hit {
+		dec |= TraceHit
+	}
+
+	return dec
+
+}
+
+// OverlapsAxisStats is the pruning statistics variant of Overlaps;
+// collects overlapping boxes like Overlaps, counting the non-empty subtrees (or leaf buckets) pruned per split axis.
+//
+// A subtree counts for the axis of the level it was pruned at; high counts on one axis mean that axis does most of the discriminating work.
+// Like OverlapsTrace, this is a tuning tool kept out of the plain query path.
+func (boT *BOXTree) OverlapsAxisStats(vals []float64) (res []int, prunesPerAxis [2]int) {
+
+	res = []int{}
+	buf := buffers.Get().(*buffer)
+
+	buf.hook = func(lb, rb, pos, ax int, dec TraceDecision) {
+
+		if dec&TracePrune == 0 {
+			return
+		}
+
+		if rb-lb < boT.leaf {
+
+			prunesPerAxis[ax]++
+			return
+
+		}
+
+		if dec&TraceLeft == 0 && lb < pos {
+			prunesPerAxis[ax]++
+		}
+
+		if dec&TraceRight == 0 && pos < rb {
+			prunesPerAxis[ax]++
+		}
+
+	}
+
+	boT.walk(buf, vals, within, func(cn int) bool {
+
+		res = append(res, boT.idxs[cn])
+		return true
+
+	})
+
+	buf.hook = nil
+	buffers.Put(buf)
+
+	return res, prunesPerAxis
+
+}
+
+// DetailedMatch is a single result of OverlapsDetailed; Lower and Upper are the stored limits, to be treated as read-only.
+type DetailedMatch struct {
+	OriginalIndex int
+	NodePosition  int
+	Lower, Upper  []float64
+}
+
+// OverlapsDetailed is the diagnostic superset of Overlaps;
+// collects overlapping boxes along with their node position in the flat tree layout (see Positions) and their stored limits.
+func (boT *BOXTree) OverlapsDetailed(vals []float64) []DetailedMatch {
+
+	res := []DetailedMatch{}
+
+	boT.traverse(vals, func(cn int) bool {
+
+		res = append(res, DetailedMatch{boT.idxs[cn], cn, boT.lmts[2*cn], boT.lmts[2*cn+1]})
+		return true
+
+	})
+
+	return res
+
+}
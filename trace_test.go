@@ -0,0 +1,170 @@
+package boxtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestOverlapsTraceHitsMatchOverlaps(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(12))
+
+	for _, lf := range []int{0, 8} {
+
+		bxs := randomBoxes(rng, 2000, 100, 10)
+		tree := NewBOXTree(bxs, WithLeafSize(lf))
+
+		for q := 0; q < 300; q++ {
+
+			vals := []float64{rng.Float64() * 110, rng.Float64() * 110}
+			hits := []int{}
+
+			for _, stp := range tree.OverlapsTrace(vals) {
+
+				if stp.Decision&TraceHit != 0 {
+					hits = append(hits, stp.Index)
+				}
+
+			}
+
+			if got, want := sorted(hits), sorted(tree.Overlaps(vals)); !equalInts(got, want) {
+				t.Fatalf("leaf %d, traced hits for %v = %v, want %v", lf, vals, got, want)
+			}
+
+		}
+
+	}
+
+}
+
+func TestOverlapsTraceEmpty(t *testing.T) {
+
+	if stps := NewBOXTree(nil).OverlapsTrace([]float64{0, 0}); len(stps) != 0 {
+		t.Fatalf("OverlapsTrace on empty tree = %v, want no steps", stps)
+	}
+
+}
+
+// traceRange returns the range [lb, rb] whose walk step is reported under node position pos.
+func traceRange(tree *BOXTree, pos int) (lb, rb int) {
+
+	lb, rb = 0, len(tree.idxs)-1
+
+	for {
+
+		cn := (lb + rb + 1) / 2
+
+		if cn == pos || rb-lb < tree.leaf {
+			return lb, rb
+		}
+
+		if pos < cn {
+			rb = cn - 1
+		} else {
+			lb = cn + 1
+		}
+
+	}
+
+}
+
+func TestOverlapsAxisStatsSumToPrunes(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(13))
+
+	for _, lf := range []int{0, 8} {
+
+		bxs := randomBoxes(rng, 2000, 100, 10)
+		tree := NewBOXTree(bxs, WithLeafSize(lf))
+
+		for q := 0; q < 300; q++ {
+
+			vals := []float64{rng.Float64() * 110, rng.Float64() * 110}
+			res, pps := tree.OverlapsAxisStats(vals)
+
+			// every non-empty child range of a visited node is either visited itself or pruned,
+			// and pruned buckets are visited ranges counting as a prune of their own
+			visited, children, buckets := 0, 0, 0
+
+			for _, stp := range tree.OverlapsTrace(vals) {
+
+				if stp.Decision == TraceHit {
+					continue
+				}
+
+				visited++
+				lb, rb := traceRange(tree, stp.Pos)
+
+				if rb-lb < tree.leaf {
+
+					if stp.Decision == TracePrune {
+						buckets++
+					}
+
+					continue
+
+				}
+
+				if lb < stp.Pos {
+					children++
+				}
+
+				if stp.Pos < rb {
+					children++
+				}
+
+			}
+
+			if want := children - (visited - 1) + buckets; pps[0]+pps[1] != want {
+				t.Fatalf("leaf %d, prunes for %v = %v, sum %d, want %d", lf, vals, pps, pps[0]+pps[1], want)
+			}
+
+			if got, want := sorted(res), bruteOverlaps(bxs, vals); !equalInts(got, want) {
+				t.Fatalf("leaf %d, OverlapsAxisStats(%v) = %v, want %v", lf, vals, got, want)
+			}
+
+		}
+
+	}
+
+}
+
+func TestOverlapsDetailedMatchesOverlaps(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(78))
+
+	for _, lf := range []int{0, 8} {
+
+		bxs := randomBoxes(rng, 1000, 100, 10)
+		tree := NewBOXTree(bxs, WithLeafSize(lf))
+		pos := tree.Positions()
+
+		for q := 0; q < 300; q++ {
+
+			vals := []float64{rng.Float64() * 110, rng.Float64() * 110}
+			dms := tree.OverlapsDetailed(vals)
+			idxs := make([]int, len(dms))
+
+			for i, dm := range dms {
+
+				if pos[dm.OriginalIndex] != dm.NodePosition || tree.idxs[dm.NodePosition] != dm.OriginalIndex {
+					t.Fatalf("leaf %d, OverlapsDetailed(%v): box %d at position %d, Positions has %d", lf, vals, dm.OriginalIndex, dm.NodePosition, pos[dm.OriginalIndex])
+				}
+
+				if l, u := bxs[dm.OriginalIndex].Limits(); dm.Lower[0] != l[0] || dm.Lower[1] != l[1] || dm.Upper[0] != u[0] || dm.Upper[1] != u[1] {
+					t.Fatalf("leaf %d, OverlapsDetailed(%v): box %d limits %v %v, want %v %v", lf, vals, dm.OriginalIndex, dm.Lower, dm.Upper, l, u)
+				}
+
+				idxs[i] = dm.OriginalIndex
+
+			}
+
+			if got, want := idxs, tree.Overlaps(vals); !equalInts(got, want) {
+				t.Fatalf("leaf %d, OverlapsDetailed(%v) indices = %v, want %v", lf, vals, got, want)
+			}
+
+		}
+
+	}
+
+}
@@ -0,0 +1,186 @@
+// MIT License
+//
+// Copyright (c) 2022 geozelot (André Siefken)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package boxtree
+
+import (
+	"fmt"
+	"math"
+)
+
+// UpdateLimits is the in-place modification function;
+// replaces the limits of the box with the given original index and re-augments the nodes on its path from the root.
+//
+// Widened limits only touch that path; shrunk limits additionally rescan the subtrees of ancestors whose augmented limit they defined.
+// Returns an error, leaving the tree unchanged, for malformed limits (ErrDimensionMismatch, ErrNonFinite, ErrInvalidBox),
+// an empty tree (ErrEmptyTree), an index not stored, or new lower limits that would break the tree order on any split axis;
+// Rebuild the tree in the latter case.
+func (boT *BOXTree) UpdateLimits(idx int, lower, upper []float64) error {
+
+	if err := boT.check(lower, upper); err != nil {
+		return fmt.Errorf("%w: box %d", err, idx)
+	}
+
+	if boT.unbounded {
+		lower, upper = unbound(lower, upper)
+	}
+
+	if len(boT.idxs) == 0 {
+		return ErrEmptyTree
+	}
+
+	p := -1
+
+	for i, v := range boT.idxs {
+
+		if v == idx {
+			p = i
+			break
+		}
+
+	}
+
+	if p < 0 {
+		return fmt.Errorf("boxtree: no box with index %d", idx)
+	}
+
+	pth := [][4]int{}
+	lb, rb, ax := 0, len(boT.idxs)-1, boT.ax0
+
+	for {
+
+		cn := int(math.Ceil(float64(lb+rb) / 2.0))
+		pth = append(pth, [4]int{lb, rb, cn, ax})
+
+		if cn == p || rb-lb < boT.leaf {
+			break
+		}
+
+		if p < cn {
+
+			if lower[ax] > boT.lmts[2*cn][ax] {
+				return fmt.Errorf("boxtree: update of box %d breaks tree order on axis %d; rebuild required", idx, ax)
+			}
+
+			rb = cn - 1
+
+		} else {
+
+			if lower[ax] < boT.lmts[2*cn][ax] {
+				return fmt.Errorf("boxtree: update of box %d breaks tree order on axis %d; rebuild required", idx, ax)
+			}
+
+			lb = cn + 1
+
+		}
+
+		ax = (ax + boT.step()) % 2
+
+	}
+
+	for i := lb; i <= rb && rb-lb >= boT.leaf; i++ {
+
+		if i < p && boT.lmts[2*i][ax] > lower[ax] || i > p && boT.lmts[2*i][ax] < lower[ax] {
+			return fmt.Errorf("boxtree: update of box %d breaks tree order on axis %d; rebuild required", idx, ax)
+		}
+
+	}
+
+	boT.detach(true)
+
+	ol, ou := boT.lmts[2*p], boT.lmts[2*p+1]
+	boT.lmts[2*p], boT.lmts[2*p+1] = lower, upper
+
+	for _, nd := range pth {
+
+		lb, rb, cn, ax := nd[0], nd[1], nd[2], nd[3]
+		ag := boT.ags[2*cn : 2*cn+2]
+
+		if upper[ax] >= ag[0] {
+			ag[0] = upper[ax]
+		} else if ou[ax] == ag[0] {
+			ag[0], _ = bounds(boT.lmts[2*lb:], rb-lb+1, ax)
+		}
+
+		if lower[ax] <= ag[1] {
+			ag[1] = lower[ax]
+		} else if ol[ax] == ag[1] {
+			_, ag[1] = bounds(boT.lmts[2*lb:], rb-lb+1, ax)
+		}
+
+	}
+
+	if boT.areas {
+		boT.ars[idx] = (upper[0] - lower[0]) * (upper[1] - lower[1])
+	}
+
+	if boT.columnar {
+
+		boT.cols[0][p], boT.cols[1][p] = lower[0], lower[1]
+		boT.cols[2][p], boT.cols[3][p] = upper[0], upper[1]
+
+	}
+
+	boT.upds++
+
+	return nil
+
+}
+
+// Reaugment is the bulk consolidation function;
+// recomputes all augmented limits (and columns with WithColumnar) from the current box limits, without re-sorting the tree.
+//
+// Useful after editing many limits in place, e.g. through the Slices returned by Box.Limits() the tree was built from.
+// The tree order is not restored, so lower limits must not have moved past their neighbors on any split axis;
+// check Validate() after such edits, or Rebuild.
+func (boT *BOXTree) Reaugment() {
+
+	boT.detach(true)
+
+	augment(boT.lmts, boT.ags, boT.idxs, boT.ax0, boT.step(), boT.leaf)
+
+	if boT.columnar {
+		boT.columns()
+	}
+
+	if boT.areas {
+		boT.measure()
+	}
+
+}
+
+// NeedsRebuild is the maintenance hint;
+// reports whether the share of boxes changed via UpdateLimits since the last build exceeds the threshold set via WithRebuildThreshold (default 0.25).
+//
+// The implicit layout stays balanced under updates, but split medians and augmented limits drift from the changed boxes,
+// weakening pruning; each successful UpdateLimits call counts, repeated updates of the same box included. Rebuild resets the count.
+func (boT *BOXTree) NeedsRebuild() bool {
+
+	th := boT.rebuild
+
+	if th <= 0 {
+		th = 0.25
+	}
+
+	return len(boT.idxs) > 0 && float64(boT.upds) > th*float64(len(boT.idxs))
+
+}
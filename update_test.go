@@ -0,0 +1,219 @@
+package boxtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestUpdateLimitsSubtreeMax(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(42))
+	bxs := randomBoxes(rng, 1000, 100, 10)
+	tree := NewBOXTree(bxs, WithLeafSize(0))
+
+	p := len(tree.idxs) / 2
+	ax := tree.ax0
+	ag := tree.ags[2*p : 2*p+2]
+
+	// the root range spans the whole tree, so its augmented max is the global max on its axis
+	max, arg := -1.0, -1
+
+	for i, bx := range bxs {
+
+		if _, u := bx.Limits(); u[ax] > max {
+			max, arg = u[ax], i
+		}
+
+	}
+
+	if ag[0] != max {
+		t.Fatalf("root augmented max = %v, want %v", ag[0], max)
+	}
+
+	// widening a box beyond the global max raises the root augmented max
+	idx := tree.idxs[0]
+	l, u := bxs[idx].Limits()
+	wide := []float64{u[0], u[1]}
+	wide[ax] = max + 20
+
+	if err := tree.UpdateLimits(idx, l, wide); err != nil {
+		t.Fatal(err)
+	}
+
+	if ag[0] != max+20 {
+		t.Fatalf("root augmented max after widening = %v, want %v", ag[0], max+20)
+	}
+
+	// shrinking it back restores the previous max, now defined by another box
+	if err := tree.UpdateLimits(idx, l, u); err != nil {
+		t.Fatal(err)
+	}
+
+	if ag[0] != max {
+		t.Fatalf("root augmented max after shrinking = %v, want %v", ag[0], max)
+	}
+
+	// an update within the bounds of a box leaves the root augmented limits untouched
+	in := tree.idxs[1]
+
+	if in == arg {
+		in = tree.idxs[2]
+	}
+
+	l, u = bxs[in].Limits()
+	prv := [2]float64{ag[0], ag[1]}
+
+	if err := tree.UpdateLimits(in, l, []float64{l[0] + (u[0]-l[0])/2, l[1] + (u[1]-l[1])/2}); err != nil {
+		t.Fatal(err)
+	}
+
+	if ag[0] != prv[0] || ag[1] != prv[1] {
+		t.Fatalf("root augmented limits after inner update = %v, want %v", ag, prv)
+	}
+
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+}
+
+func TestUpdateLimitsOrderViolation(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(43))
+	bxs := randomBoxes(rng, 500, 100, 10)
+	tree := NewBOXTree(bxs, WithLeafSize(0))
+
+	// moving the leftmost box in node order past the root split breaks the order on the root axis
+	idx := tree.idxs[0]
+	l, u := bxs[idx].Limits()
+	ax := tree.ax0
+	mv, up := []float64{l[0], l[1]}, []float64{u[0], u[1]}
+	mv[ax], up[ax] = 200, 210
+
+	if err := tree.UpdateLimits(idx, mv, up); err == nil {
+		t.Fatal("UpdateLimits past the root split: want error")
+	}
+
+	if l2, u2 := tree.lmts[0], tree.lmts[1]; l2[0] != l[0] || l2[1] != l[1] || u2[0] != u[0] || u2[1] != u[1] {
+		t.Fatalf("failed update changed limits to %v %v", l2, u2)
+	}
+
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	for q := 0; q < 100; q++ {
+
+		vals := []float64{rng.Float64() * 110, rng.Float64() * 110}
+
+		if got, want := sorted(tree.Overlaps(vals)), bruteOverlaps(bxs, vals); !equalInts(got, want) {
+			t.Fatalf("after failed update, Overlaps(%v) = %v, want %v", vals, got, want)
+		}
+
+	}
+
+}
+
+func TestUpdateLimitsMatchesRebuild(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(44))
+
+	for _, lf := range []int{0, 8} {
+
+		bxs := randomBoxes(rng, 1000, 100, 10)
+		tree := NewBOXTree(bxs, WithLeafSize(lf))
+		cur := append([]Box{}, bxs...)
+		ok := 0
+
+		for n := 0; n < 500; n++ {
+
+			idx := rng.Intn(len(cur))
+			l, _ := cur[idx].Limits()
+			nl := []float64{l[0] + rng.NormFloat64()*0.1, l[1] + rng.NormFloat64()*0.1}
+			nu := []float64{nl[0] + rng.Float64()*20, nl[1] + rng.Float64()*20}
+
+			if err := tree.UpdateLimits(idx, nl, nu); err != nil {
+				continue
+			}
+
+			cur[idx] = &testBox{nl, nu}
+			ok++
+
+		}
+
+		if ok == 0 {
+			t.Fatalf("leaf %d: no update succeeded", lf)
+		}
+
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("leaf %d: %v", lf, err)
+		}
+
+		fresh := NewBOXTree(cur, WithLeafSize(lf))
+
+		for q := 0; q < 300; q++ {
+
+			vals := []float64{rng.Float64() * 130, rng.Float64() * 130}
+			want := bruteOverlaps(cur, vals)
+
+			if got := sorted(tree.Overlaps(vals)); !equalInts(got, want) {
+				t.Fatalf("leaf %d, updated Overlaps(%v) = %v, want %v", lf, vals, got, want)
+			}
+
+			if got := sorted(fresh.Overlaps(vals)); !equalInts(got, want) {
+				t.Fatalf("leaf %d, rebuilt Overlaps(%v) = %v, want %v", lf, vals, got, want)
+			}
+
+		}
+
+	}
+
+}
+
+func TestReaugmentAfterBulkEdits(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(45))
+
+	for _, opts := range [][]Option{{WithLeafSize(0)}, {WithLeafSize(8)}, {WithColumnar()}} {
+
+		bxs := randomBoxes(rng, 1000, 100, 10)
+		tree := NewBOXTree(bxs, opts...)
+
+		// grow and shrink upper limits in place through the Slices the tree was built from
+		for _, bx := range bxs {
+
+			l, u := bx.Limits()
+			u[0], u[1] = l[0]+rng.Float64()*25, l[1]+rng.Float64()*25
+
+		}
+
+		if err := tree.Validate(); err == nil {
+			t.Fatal("Validate after in-place edits without Reaugment: want error")
+		}
+
+		tree.Reaugment()
+
+		if err := tree.Validate(); err != nil {
+			t.Fatal(err)
+		}
+
+		fresh := NewBOXTree(bxs, opts...)
+
+		for q := 0; q < 300; q++ {
+
+			vals := []float64{rng.Float64() * 130, rng.Float64() * 130}
+			want := bruteOverlaps(bxs, vals)
+
+			if got := sorted(tree.Overlaps(vals)); !equalInts(got, want) {
+				t.Fatalf("reaugmented Overlaps(%v) = %v, want %v", vals, got, want)
+			}
+
+			if got := sorted(fresh.Overlaps(vals)); !equalInts(got, want) {
+				t.Fatalf("rebuilt Overlaps(%v) = %v, want %v", vals, got, want)
+			}
+
+		}
+
+	}
+
+}
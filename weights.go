@@ -0,0 +1,157 @@
+// MIT License
+//
+// Copyright (c) 2022 geozelot (André Siefken)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package boxtree
+
+import (
+	"fmt"
+	"math"
+)
+
+// NewBOXTreeWeighted is the weighted initialization function;
+// creates the tree from the given Slice of Box, attaching the weight at the same index to each box for NearestWeighted.
+//
+// Panics if the number of weights differs from the number of boxes; NewBOXTreeWeightedChecked returns an error instead.
+// Weights are referenced, not copied; after changing them, Rebuild the tree to refresh the subtree maxima used for pruning.
+func NewBOXTreeWeighted(bxs []Box, weights []float64, opts ...Option) *BOXTree {
+
+	if len(weights) != len(bxs) {
+		panic("boxtree: number of weights does not match number of boxes")
+	}
+
+	boT := BOXTree{wts: weights}
+
+	for _, opt := range opts {
+		opt(&boT)
+	}
+
+	boT.buildTree(bxs)
+
+	return &boT
+
+}
+
+// NewBOXTreeWeightedChecked is the guarded variant of NewBOXTreeWeighted;
+// returns ErrDimensionMismatch if the number of weights differs from the number of boxes, otherwise behaves like NewBOXTreeChecked.
+func NewBOXTreeWeightedChecked(bxs []Box, weights []float64, opts ...Option) (*BOXTree, error) {
+
+	if len(weights) != len(bxs) {
+		return nil, fmt.Errorf("%w: %d weights for %d boxes", ErrDimensionMismatch, len(weights), len(bxs))
+	}
+
+	return NewBOXTreeChecked(bxs, append(opts[:len(opts):len(opts)], func(boT *BOXTree) {
+		boT.wts = weights
+	})...)
+
+}
+
+// NearestWeighted is the weighted variant of Nearest;
+// finds the box minimizing its Euclidean distance to the given values divided by its weight, returning its index and that score.
+//
+// Returns -1 and +Inf for empty or unweighted trees. Boxes with a weight of zero or below never match,
+// and boxes collapsed by WithDedup are scored by the weight of the box kept in the tree.
+// Subtrees are pruned by their distance bound divided by the maximum weight within them, so the search stays exact.
+func (boT *BOXTree) NearestWeighted(vals []float64) (idx int, score float64) {
+
+	idx, score = -1, math.Inf(1)
+
+	if boT.wts == nil {
+		return idx, score
+	}
+
+	descend(nil, len(boT.idxs), boT.ax0, boT.step(), 2, func(lb, rb, cn, ax int) (left, right, ok bool) {
+
+		ag, wm := boT.ags[2*cn:2*cn+2], boT.wmx[cn]
+
+		if wm <= 0 || gap(vals[ax], ag[1], ag[0])/wm > score {
+			return false, false, true
+		}
+
+		if rb-lb < boT.leaf {
+
+			for i := lb; i <= rb; i++ {
+
+				if w := boT.wts[boT.idxs[i]]; w > 0 {
+
+					if s := distance(boT.lmts[2*i], boT.lmts[2*i+1], vals) / w; s < score {
+						idx, score = boT.idxs[i], s
+					}
+
+				}
+
+			}
+
+			return false, false, true
+
+		}
+
+		l := boT.lmts[2*cn]
+
+		if w := boT.wts[boT.idxs[cn]]; w > 0 {
+
+			if s := distance(l, boT.lmts[2*cn+1], vals) / w; s < score {
+				idx, score = boT.idxs[cn], s
+			}
+
+		}
+
+		return true, l[ax]-vals[ax] <= score*wm, true
+
+	})
+
+	return idx, score
+
+}
+
+// weigh is the internal weight bound function; computes the maximum weight of each subtree by node position.
+//
+// Panics if the number of weights differs from the number of boxes the tree was (re)built from; RebuildChecked returns an error instead.
+func (boT *BOXTree) weigh() {
+
+	if len(boT.wts) != boT.size {
+		panic("boxtree: number of weights does not match number of boxes")
+	}
+
+	if cap(boT.wmx) < len(boT.idxs) {
+		boT.wmx = make([]float64, len(boT.idxs))
+	} else {
+		boT.wmx = boT.wmx[:len(boT.idxs)]
+	}
+
+	maxWeight(boT.wmx, boT.wts, boT.idxs)
+
+}
+
+// maxWeight is an internal utility function, storing the maximum weight of each range on its midpoint node and returning it.
+func maxWeight(wmx, wts []float64, idxs []int) float64 {
+
+	if len(idxs) == 0 {
+		return math.Inf(-1)
+	}
+
+	r := len(idxs) >> 1
+
+	wmx[r] = math.Max(wts[idxs[r]], math.Max(maxWeight(wmx[:r], wts, idxs[:r]), maxWeight(wmx[r+1:], wts, idxs[r+1:])))
+
+	return wmx[r]
+
+}
@@ -0,0 +1,87 @@
+package boxtree
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestNearestWeightedMatchesBruteForce(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(14))
+
+	for _, lf := range []int{0, 8} {
+
+		bxs := randomBoxes(rng, 2000, 100, 5)
+		wts := make([]float64, len(bxs))
+
+		for i := range wts {
+
+			if wts[i] = rng.Float64() * 4; i%10 == 0 {
+				wts[i] = 0
+			}
+
+		}
+
+		tree := NewBOXTreeWeighted(bxs, wts, WithLeafSize(lf))
+
+		for q := 0; q < 300; q++ {
+
+			vals := []float64{rng.Float64()*140 - 20, rng.Float64()*140 - 20}
+			want := math.Inf(1)
+
+			for i, bx := range bxs {
+
+				if wts[i] > 0 {
+
+					l, u := bx.Limits()
+					want = math.Min(want, distance(l, u, vals)/wts[i])
+
+				}
+
+			}
+
+			if idx, score := tree.NearestWeighted(vals); score != want || wts[idx] <= 0 {
+				t.Fatalf("leaf %d, NearestWeighted(%v) = %d, %g, want score %g", lf, vals, idx, score, want)
+			}
+
+		}
+
+	}
+
+}
+
+func TestNearestWeightedUnweighted(t *testing.T) {
+
+	tree := NewBOXTree(randomBoxes(rand.New(rand.NewSource(15)), 10, 100, 5))
+
+	if idx, score := tree.NearestWeighted([]float64{50, 50}); idx != -1 || !math.IsInf(score, 1) {
+		t.Fatalf("NearestWeighted on unweighted tree = %d, %g, want -1, +Inf", idx, score)
+	}
+
+}
+
+func TestNewBOXTreeWeightedChecked(t *testing.T) {
+
+	bxs := randomBoxes(rand.New(rand.NewSource(16)), 10, 100, 5)
+
+	if _, err := NewBOXTreeWeightedChecked(bxs, make([]float64, 9)); !errors.Is(err, ErrDimensionMismatch) {
+		t.Fatalf("NewBOXTreeWeightedChecked with 9 weights for 10 boxes: err = %v, want ErrDimensionMismatch", err)
+	}
+
+	tree, err := NewBOXTreeWeightedChecked(bxs, make([]float64, 10))
+
+	if err != nil {
+		t.Fatalf("NewBOXTreeWeightedChecked: %v", err)
+	}
+
+	if err := tree.RebuildChecked(bxs[:5]); !errors.Is(err, ErrDimensionMismatch) {
+		t.Fatalf("RebuildChecked with 5 boxes for 10 weights: err = %v, want ErrDimensionMismatch", err)
+	}
+
+	if tree.Len() != 10 {
+		t.Fatalf("rejected RebuildChecked changed the tree to %d boxes", tree.Len())
+	}
+
+}
@@ -0,0 +1,100 @@
+// MIT License
+//
+// Copyright (c) 2022 geozelot (André Siefken)
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package boxtree
+
+// NewBOXTreeWrapped is the toroidal initialization function;
+// creates the tree from the given Slice of Box, configured by the given Options, in a coordinate space wrapping around
+// with the given period per axis (0 for no wrapping).
+//
+// Point queries then also match boxes against the values shifted by ±period, so boxes extending past the seam
+// (e.g. longitude 170 to 190) match values on the opposite side (e.g. -175). Boxes and values are expected
+// to lie within one period of each other; boxes wider than the period match everywhere on that axis.
+//
+// This covers every query on the shared point traversal: Overlaps and its Overlaps... variants taking values, OverlapsCustom,
+// OverlapsChecked, Do, Query.Overlaps, HitCounts, FirstHit, DistinctHits and OverlapsTrace, each reporting a box once per query
+// (WithColumnar scans do not apply). Measurements derived from the values, e.g. edge distances in OverlapsEdgeDistance, hit kinds or
+// quadrants, use the shift each box matched at, and OverlapsCustom predicates receive the shifted values. The period is ignored by queries on the box traversal (OverlapMatrix, OverlapsBoxBounds,
+// OverlapsBoxSeq, OverlapsTile, OverlapsAxis, OverlapsWithMargin, WithinEllipse, TopKByOverlapArea, Breakpoints, OverlapDegrees),
+// by the coverage and area functions, and by Nearest, NearestFunc, NearestWeighted and RankByAxis.
+func NewBOXTreeWrapped(bxs []Box, period []float64, opts ...Option) *BOXTree {
+
+	boT := BOXTree{}
+
+	for _, opt := range opts {
+		opt(&boT)
+	}
+
+	boT.period = period
+	boT.buildTree(bxs)
+
+	return &boT
+
+}
+
+// wrapWalk is the toroidal variant of walk;
+// walks the tree for the values and each of their shifts by ±period, passing the node position of each box accepted by hit to fn once,
+// along with the shifted values it matched at.
+func (boT *BOXTree) wrapWalk(buf *buffer, vals []float64, hit func(l, u, vals []float64) bool, fn func(cn int, at []float64) bool) {
+
+	seen := map[int]bool{}
+	done := false
+
+	for _, dx := range shifts(boT.period[0]) {
+
+		for _, dy := range shifts(boT.period[1]) {
+
+			if done || buf.err != nil {
+				return
+			}
+
+			at := []float64{vals[0] + dx, vals[1] + dy}
+
+			boT.probe(buf, at, hit, func(cn int) bool {
+
+				if seen[cn] {
+					return true
+				}
+
+				seen[cn] = true
+				done = !fn(cn, at)
+
+				return !done
+
+			})
+
+		}
+
+	}
+
+}
+
+// shifts is an internal utility function, listing the offsets to query for the given period.
+func shifts(period float64) []float64 {
+
+	if period == 0 {
+		return []float64{0}
+	}
+
+	return []float64{0, -period, period}
+
+}
@@ -0,0 +1,135 @@
+package boxtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// bruteWrapped returns the ascending indices of all boxes containing vals or any of its shifts by ±period.
+func bruteWrapped(bxs []Box, vals, period []float64) []int {
+
+	res := []int{}
+
+	for i, bx := range bxs {
+
+		l, u := bx.Limits()
+		hit := true
+
+		for ax := 0; ax < 2 && hit; ax++ {
+
+			hit = false
+
+			for _, d := range shifts(period[ax]) {
+				hit = hit || l[ax] <= vals[ax]+d && vals[ax]+d <= u[ax]
+			}
+
+		}
+
+		if hit {
+			res = append(res, i)
+		}
+
+	}
+
+	return res
+
+}
+
+func TestWrappedVariants(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(36))
+	period := []float64{360, 0}
+	bxs := make([]Box, 1000)
+
+	for i := range bxs {
+
+		x, y := rng.Float64()*360-180, rng.Float64()*180-90
+		bxs[i] = NewRect(x, y, x+rng.Float64()*40, y+rng.Float64()*10)
+
+	}
+
+	tree := NewBOXTreeWrapped(bxs, period, WithLeafSize(8), WithColumnar())
+	qP := NewQueryPool(tree)
+
+	for q := 0; q < 200; q++ {
+
+		vals := []float64{rng.Float64()*360 - 180, rng.Float64()*180 - 90}
+
+		if q%4 == 0 {
+			vals[0] = -180 + rng.Float64()*20
+		}
+
+		want := bruteWrapped(bxs, vals, period)
+
+		for name, got := range map[string][]int{
+			"Overlaps":       tree.Overlaps(vals),
+			"OverlapsDFS":    tree.OverlapsDFS(vals),
+			"Query.Overlaps": append([]int{}, qP.Get().Overlaps(vals)...),
+			"Do":             tree.Do(&Query{Vals: vals}),
+		} {
+
+			if got = sorted(got); !equalInts(got, want) {
+				t.Fatalf("%s(%v) = %v, want %v", name, vals, got, want)
+			}
+
+		}
+
+		cnt := 0
+
+		for _, c := range tree.HitCounts([][]float64{vals}) {
+			cnt += c
+		}
+
+		if cnt != len(want) {
+			t.Fatalf("HitCounts(%v) sums to %d, want %d", vals, cnt, len(want))
+		}
+
+		if len(want) > 0 && len(tree.OverlapsMode(vals, ModeInclusive)) != len(want) {
+			t.Fatalf("OverlapsMode(%v) misses wrapped boxes", vals)
+		}
+
+	}
+
+}
+
+func TestWrappedMeasuresAtShift(t *testing.T) {
+
+	// box 0 crosses the seam at 180, box 1 lies on the far side of it
+	tree := NewBOXTreeWrapped([]Box{NewRect(170, 0, 190, 10), NewRect(-180, 0, -170, 10)}, []float64{360, 0})
+	vals := []float64{-175, 5}
+
+	dst := map[int]float64{}
+
+	for _, m := range tree.OverlapsEdgeDistance(vals) {
+		dst[m.Index] = m.EdgeDist
+	}
+
+	if len(dst) != 2 || dst[0] != 5 || dst[1] != 5 {
+		t.Fatalf("OverlapsEdgeDistance(%v) = %v, want 5 for both boxes", vals, dst)
+	}
+
+	if inside, edge := tree.OverlapsTouchSplit([]float64{-180, 5}); len(inside) != 1 || inside[0] != 0 || len(edge) != 1 || edge[0] != 1 {
+		t.Fatalf("OverlapsTouchSplit = %v, %v, want [0], [1]", inside, edge)
+	}
+
+	// -170 is the upper edge of box 1 and, shifted to 190, that of box 0
+	hks := tree.OverlapsHitKind([]float64{-170, 5})
+
+	if len(hks) != 2 {
+		t.Fatalf("OverlapsHitKind = %v, want both boxes", hks)
+	}
+
+	for _, hr := range hks {
+
+		if hr.Kind != Edge {
+			t.Fatalf("OverlapsHitKind: box %d is %v", hr.Index, hr.Kind)
+		}
+
+	}
+
+	// at -172 the box centers lie at 180 (shifted: -180) and -175, both left of the value
+	if qs := tree.OverlapsQuadrants([]float64{-172, 6}); len(qs[3]) != 2 {
+		t.Fatalf("OverlapsQuadrants = %v, want both boxes in quadrant 3", qs)
+	}
+
+}